@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"laundry-pos/config"
+	"laundry-pos/controllers"
+	"log"
+	"os"
+	"time"
+)
+
+// Menjalankan rekonsiliasi pembayaran sekali dari command line, misalnya lewat crontab:
+//
+//	go run ./cmd/reconcile -older-than 15m
+func main() {
+	olderThan := flag.Duration("older-than", 15*time.Minute, "hanya periksa pembayaran pending yang lebih lama dari durasi ini")
+	flag.Parse()
+	if *olderThan <= 0 {
+		log.Fatal("-older-than harus lebih dari 0")
+	}
+
+	if err := config.InitMongoDB(); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := controllers.RunReconcileJob(ctx, time.Now(), *olderThan)
+	if err != nil {
+		log.Fatal("Rekonsiliasi pembayaran gagal: ", err)
+	}
+
+	json.NewEncoder(os.Stdout).Encode(result)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/controllers"
+	"log"
+	"os"
+	"time"
+)
+
+// Menjalankan job pengingat sekali dari command line, misalnya lewat crontab:
+//
+//	go run ./cmd/reminders
+func main() {
+	if err := config.InitMongoDB(); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := controllers.RunReminderJob(ctx, time.Now())
+	if err != nil {
+		log.Fatal("Job pengingat gagal: ", err)
+	}
+
+	json.NewEncoder(os.Stdout).Encode(result)
+}
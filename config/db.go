@@ -5,6 +5,7 @@ import (
     "log"
     "time"
 
+    "go.mongodb.org/mongo-driver/bson"
     "go.mongodb.org/mongo-driver/mongo"
     "go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -16,6 +17,16 @@ var InventoryCollection *mongo.Collection
 var PaymentCollection *mongo.Collection
 var ServiceCollection *mongo.Collection
 var TransactionCollection *mongo.Collection
+var SettingsCollection *mongo.Collection
+var CounterCollection *mongo.Collection
+var NotificationCollection *mongo.Collection
+var ReminderCollection *mongo.Collection
+var RefundCollection *mongo.Collection
+var ShiftCollection *mongo.Collection
+var CashMovementCollection *mongo.Collection
+var WebhookEventCollection *mongo.Collection
+var StockMovementCollection *mongo.Collection
+var TrackingAttemptCollection *mongo.Collection
 
 // InitMongoDB untuk menginisialisasi koneksi ke MongoDB
 func InitMongoDB() error {
@@ -44,6 +55,93 @@ func InitMongoDB() error {
 	PaymentCollection = client.Database("laundry-pos").Collection("payments")
 	ServiceCollection = client.Database("laundry-pos").Collection("service")
 	TransactionCollection = client.Database("laundry-pos").Collection("transactions")
+	SettingsCollection = client.Database("laundry-pos").Collection("settings")
+	CounterCollection = client.Database("laundry-pos").Collection("counters")
+	NotificationCollection = client.Database("laundry-pos").Collection("notifications")
+	ReminderCollection = client.Database("laundry-pos").Collection("reminders")
+	RefundCollection = client.Database("laundry-pos").Collection("refunds")
+	ShiftCollection = client.Database("laundry-pos").Collection("shifts")
+	CashMovementCollection = client.Database("laundry-pos").Collection("cash_movements")
+	WebhookEventCollection = client.Database("laundry-pos").Collection("webhook_events")
+	StockMovementCollection = client.Database("laundry-pos").Collection("stock_movements")
+	TrackingAttemptCollection = client.Database("laundry-pos").Collection("tracking_attempts")
+
+	// Kunci idempoten pembayaran harus unik, sparse agar pembayaran tanpa kunci tidak saling bentrok
+	_, err = PaymentCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "idempotency_key", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		log.Println("Gagal membuat index idempotency_key: ", err)
+	}
+
+	// Nomor order dipakai untuk lacak order dan order_id gateway sehingga harus unik,
+	// sparse karena transaksi lama belum memiliki nomor order
+	_, err = TransactionCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orderNumber", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		log.Println("Gagal membuat index orderNumber: ", err)
+	}
+
+	// Satu kasir hanya boleh memiliki satu shift terbuka, termasuk saat dua request buka shift masuk bersamaan
+	_, err = ShiftCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "cashierId", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"status": "open"}),
+	})
+	if err != nil {
+		log.Println("Gagal membuat index shift terbuka: ", err)
+	}
+
+	// Kode tag dipakai untuk mencari pemilik cucian sehingga tidak boleh dipakai dua transaksi.
+	// Transaksi tanpa potongan tidak memiliki field tagCodes dan tidak ikut diindeks.
+	_, err = TransactionCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "tagCodes", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"tagCodes": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		log.Println("Gagal membuat index tagCodes: ", err)
+	}
+
+	// Index untuk laporan, dashboard, piutang, dan perhitungan ulang pelunasan
+	_, err = TransactionCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "transactionDate", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "estimatedCompletion", Value: 1}}},
+		{Keys: bson.D{{Key: "customerId", Value: 1}, {Key: "transactionDate", Value: 1}}},
+	})
+	if err != nil {
+		log.Println("Gagal membuat index transactions: ", err)
+	}
+	_, err = PaymentCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "transactionId", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "order_id", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "settled_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		log.Println("Gagal membuat index payments: ", err)
+	}
+
+	// Hitungan percobaan lacak order yang gagal hanya berlaku satu jendela waktu, hapus setelah kedaluwarsa
+	_, err = TrackingAttemptCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Println("Gagal membuat index TTL tracking_attempts: ", err)
+	}
+
+	// Notifikasi gateway yang ditolak berasal dari pengirim tak dikenal, hapus otomatis setelah 7 hari
+	_, err = WebhookEventCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "receivedAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60).
+			SetPartialFilterExpression(bson.M{"result": "rejected"}),
+	})
+	if err != nil {
+		log.Println("Gagal membuat index TTL webhook_events: ", err)
+	}
 
     return nil
 }
@@ -21,7 +21,7 @@ func Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Tetapkan role default menjadi "staff"
-	user.Role = "staff"
+	user.Role = models.RoleStaff
 
 	// Cek apakah username sudah digunakan
 	var existingUser models.User
@@ -82,7 +82,7 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate token JWT
-	token, err := utils.GenerateJWT(user.Username, user.ID.String(), user.Role)
+	token, err := utils.GenerateJWT(user.ID.Hex(), user.Role, user.Username)
 	if err != nil {
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
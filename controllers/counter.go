@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Dokumen penghitung nomor urut di koleksi counters
+type counter struct {
+	ID  string `bson:"_id"`
+	Seq int64  `bson:"seq"`
+}
+
+// nextSequence menaikkan penghitung secara atomik dan mengembalikan nilai barunya
+func nextSequence(ctx context.Context, key string) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var result counter
+	err := config.CounterCollection.FindOneAndUpdate(ctx, bson.M{"_id": key}, bson.M{"$inc": bson.M{"seq": 1}}, opts).Decode(&result)
+	if err != nil {
+		return 0, err
+	}
+	return result.Seq, nil
+}
+
+// nextOrderNumber membuat nomor order berurutan per hari, misalnya LDR-20261018-0042
+func nextOrderNumber(ctx context.Context, settings models.ShopSettings, at time.Time) (string, error) {
+	date := at.In(utils.ShopLocation(settings)).Format("20060102")
+	seq, err := nextSequence(ctx, "order-"+settings.InvoicePrefix+"-"+date)
+	if err != nil {
+		return "", fmt.Errorf("Gagal membuat nomor order")
+	}
+	return fmt.Sprintf("%s-%s-%04d", settings.InvoicePrefix, date, seq), nil
+}
@@ -128,6 +128,8 @@ func UpdateCustomer(w http.ResponseWriter, r *http.Request) {
 			"fullName":   updatedCustomer.FullName,
 			"phoneNumber": updatedCustomer.PhoneNumber,
 			"email":      updatedCustomer.Email,
+			"language":   updatedCustomer.Language,
+			"notifyVia":  updatedCustomer.NotifyVia,
 		},
 	}
 
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/utils"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Jumlah layanan terlaris yang ditampilkan di dashboard
+const dashboardTopServices = 5
+
+// CustomerMix adalah jumlah customer baru (order pertama dalam periode) dan customer lama
+type CustomerMix struct {
+	New       int `json:"new"`
+	Returning int `json:"returning"`
+}
+
+// DashboardKPIs adalah angka ringkasan untuk halaman dashboard
+type DashboardKPIs struct {
+	Date         string         `json:"date"`
+	OrdersToday  int            `json:"ordersToday"`
+	SalesToday   float64        `json:"salesToday"`   // Total tagihan order yang masuk hari ini
+	RevenueToday float64        `json:"revenueToday"` // Pembayaran bersih yang diterima hari ini
+	StatusCounts map[string]int `json:"statusCounts"` // Order aktif per status, Completed dihitung yang selesai hari ini
+	DueToday     int            `json:"dueToday"`     // Belum siap dan dijanjikan selesai hari ini
+	Overdue      int            `json:"overdue"`      // Belum siap padahal sudah lewat perkiraan selesai
+	Customers    struct {
+		Today      CustomerMix `json:"today"`
+		Last30Days CustomerMix `json:"last30Days"`
+	} `json:"customers"`
+	TopServices []ServiceSales `json:"topServices"` // 30 hari terakhir
+}
+
+// buildDashboard menghitung KPI dashboard dengan satu agregasi $facet pada transaksi,
+// ditambah penjumlahan pembayaran hari ini dan tanggal order pertama customer
+func buildDashboard(ctx context.Context, now time.Time, loc *time.Location) (DashboardKPIs, error) {
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	tomorrow := today.AddDate(0, 0, 1)
+	since := today.AddDate(0, 0, -29)
+	active := []string{models.TransactionPending, models.TransactionProcessing, models.TransactionReady}
+	notReady := []string{models.TransactionPending, models.TransactionProcessing}
+
+	kpis := DashboardKPIs{
+		Date: today.Format("2006-01-02"),
+		StatusCounts: map[string]int{
+			models.TransactionPending:    0,
+			models.TransactionProcessing: 0,
+			models.TransactionReady:      0,
+			models.TransactionCompleted:  0,
+		},
+		TopServices: []ServiceSales{},
+	}
+
+	count := bson.M{"$group": bson.M{"_id": nil, "count": bson.M{"$sum": 1}}}
+	pipeline := mongo.Pipeline{
+		// Hanya order 30 hari terakhir, order yang masih aktif, dan yang selesai hari ini yang perlu dibaca
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"transactionDate": bson.M{"$gte": since}},
+			bson.M{"status": bson.M{"$in": active}},
+			bson.M{"completedAt": bson.M{"$gte": today}},
+		}}}},
+		{{Key: "$facet", Value: bson.M{
+			"today": bson.A{
+				bson.M{"$match": bson.M{"transactionDate": bson.M{"$gte": today, "$lt": tomorrow}}},
+				bson.M{"$group": bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "sales": bson.M{"$sum": "$totalAmount"}}},
+			},
+			"statuses": bson.A{
+				bson.M{"$match": bson.M{"status": bson.M{"$in": active}}},
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+			},
+			"completedToday": bson.A{
+				bson.M{"$match": bson.M{"status": models.TransactionCompleted, "completedAt": bson.M{"$gte": today}}},
+				count,
+			},
+			"dueToday": bson.A{
+				bson.M{"$match": bson.M{"status": bson.M{"$in": notReady}, "estimatedCompletion": bson.M{"$gte": now, "$lt": tomorrow}}},
+				count,
+			},
+			"overdue": bson.A{
+				// Order tanpa perkiraan selesai menyimpan tanggal nol sehingga dibatasi dengan $gt
+				bson.M{"$match": bson.M{"status": bson.M{"$in": notReady}, "estimatedCompletion": bson.M{"$gt": time.Time{}, "$lt": now}}},
+				count,
+			},
+			"topServices": bson.A{
+				bson.M{"$match": bson.M{"transactionDate": bson.M{"$gte": since}}},
+				bson.M{"$unwind": "$items"},
+				bson.M{"$group": bson.M{
+					"_id":         "$items.serviceId",
+					"serviceName": bson.M{"$first": "$items.service.serviceName"},
+					"unit":        bson.M{"$first": "$items.service.unit"},
+					"quantity":    bson.M{"$sum": "$items.quantity"},
+					"orders":      bson.M{"$sum": 1},
+					"sales":       bson.M{"$sum": "$items.totalPrice"},
+				}},
+				bson.M{"$sort": bson.M{"sales": -1}},
+				bson.M{"$limit": dashboardTopServices},
+			},
+			"customers": bson.A{
+				bson.M{"$match": bson.M{"transactionDate": bson.M{"$gte": since}}},
+				bson.M{"$group": bson.M{
+					"_id":          "$customerId",
+					"orderedToday": bson.M{"$max": bson.M{"$gte": bson.A{"$transactionDate", today}}},
+				}},
+			},
+		}}},
+	}
+
+	type countResult struct {
+		ID    interface{} `bson:"_id"`
+		Count int         `bson:"count"`
+		Sales float64     `bson:"sales"`
+	}
+	var facets []struct {
+		Today          []countResult  `bson:"today"`
+		Statuses       []countResult  `bson:"statuses"`
+		CompletedToday []countResult  `bson:"completedToday"`
+		DueToday       []countResult  `bson:"dueToday"`
+		Overdue        []countResult  `bson:"overdue"`
+		TopServices    []ServiceSales `bson:"topServices"`
+		Customers      []struct {
+			ID           primitive.ObjectID `bson:"_id"`
+			OrderedToday bool               `bson:"orderedToday"`
+		} `bson:"customers"`
+	}
+	if err := aggregateAll(ctx, config.TransactionCollection, pipeline, &facets); err != nil {
+		return kpis, err
+	}
+	result := facets[0]
+
+	if len(result.Today) > 0 {
+		kpis.OrdersToday, kpis.SalesToday = result.Today[0].Count, result.Today[0].Sales
+	}
+	for _, status := range result.Statuses {
+		if name, ok := status.ID.(string); ok {
+			kpis.StatusCounts[name] = status.Count
+		}
+	}
+	if len(result.CompletedToday) > 0 {
+		kpis.StatusCounts[models.TransactionCompleted] = result.CompletedToday[0].Count
+	}
+	if len(result.DueToday) > 0 {
+		kpis.DueToday = result.DueToday[0].Count
+	}
+	if len(result.Overdue) > 0 {
+		kpis.Overdue = result.Overdue[0].Count
+	}
+	kpis.TopServices = append(kpis.TopServices, result.TopServices...)
+
+	paidToday := paidBetween(today, tomorrow)
+	paidToday["status"] = bson.M{"$in": collectedPaymentStatuses}
+	revenue, err := sumField(ctx, config.PaymentCollection, paidToday, netPaymentAmount)
+	if err != nil {
+		return kpis, err
+	}
+	kpis.RevenueToday = revenue
+
+	// Customer baru adalah yang order pertamanya (sepanjang waktu) jatuh di dalam periode
+	if len(result.Customers) == 0 {
+		return kpis, nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(result.Customers))
+	for _, customer := range result.Customers {
+		ids = append(ids, customer.ID)
+	}
+	var firstOrders []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		First time.Time          `bson:"first"`
+	}
+	firstPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"customerId": bson.M{"$in": ids}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$customerId", "first": bson.M{"$min": "$transactionDate"}}}},
+	}
+	if err := aggregateAll(ctx, config.TransactionCollection, firstPipeline, &firstOrders); err != nil {
+		return kpis, err
+	}
+	firstOrder := map[primitive.ObjectID]time.Time{}
+	for _, order := range firstOrders {
+		firstOrder[order.ID] = order.First
+	}
+
+	for _, customer := range result.Customers {
+		first := firstOrder[customer.ID]
+		if first.Before(since) {
+			kpis.Customers.Last30Days.Returning++
+		} else {
+			kpis.Customers.Last30Days.New++
+		}
+		if customer.OrderedToday {
+			if first.Before(today) {
+				kpis.Customers.Today.Returning++
+			} else {
+				kpis.Customers.Today.New++
+			}
+		}
+	}
+	return kpis, nil
+}
+
+// Fungsi untuk mengambil KPI dashboard dalam satu panggilan
+func GetDashboard(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	kpis, err := buildDashboard(ctx, time.Now(), utils.ShopLocation(loadShopSettings(ctx)))
+	if err != nil {
+		http.Error(w, "Gagal menghitung data dashboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(kpis)
+}
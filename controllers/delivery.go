@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/utils"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// prepareLogistics memvalidasi data jemput/antar, menghitung ongkos zona, dan mengecek kurir
+func prepareLogistics(ctx context.Context, logistics *models.Logistics, settings models.ShopSettings) error {
+	if logistics == nil {
+		return nil
+	}
+	if strings.TrimSpace(logistics.Address) == "" {
+		return fmt.Errorf("Alamat antar-jemput wajib diisi")
+	}
+	if !logistics.Window.Start.IsZero() && !logistics.Window.End.After(logistics.Window.Start) {
+		return fmt.Errorf("Rentang waktu antar-jemput tidak valid")
+	}
+
+	zone, err := findDeliveryZone(settings.DeliveryZones, logistics.DistanceKm)
+	if err != nil {
+		return err
+	}
+	logistics.Zone = zone.Name
+	logistics.Fee = zone.Fee
+
+	if !logistics.CourierID.IsZero() {
+		courier, err := findCourier(ctx, logistics.CourierID)
+		if err != nil {
+			return err
+		}
+		logistics.CourierName = courier.Username
+	}
+
+	if logistics.Status == "" {
+		logistics.Status = models.LogisticsScheduled
+	}
+	return nil
+}
+
+// findDeliveryZone memilih zona terkecil yang mencakup jarak, tanpa zona berarti gratis ongkos
+func findDeliveryZone(zones []models.DeliveryZone, distanceKm float64) (models.DeliveryZone, error) {
+	if distanceKm < 0 {
+		return models.DeliveryZone{}, fmt.Errorf("Jarak tidak boleh negatif")
+	}
+	if len(zones) == 0 {
+		return models.DeliveryZone{}, nil
+	}
+
+	sorted := append([]models.DeliveryZone{}, zones...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MaxDistanceKm < sorted[j].MaxDistanceKm })
+	for _, zone := range sorted {
+		if distanceKm <= zone.MaxDistanceKm {
+			return zone, nil
+		}
+	}
+	return models.DeliveryZone{}, fmt.Errorf("Jarak %.1f km di luar jangkauan antar-jemput", distanceKm)
+}
+
+// findCourier memastikan user yang ditugaskan memiliki role kurir
+func findCourier(ctx context.Context, courierID primitive.ObjectID) (models.User, error) {
+	var courier models.User
+	err := config.UserCollection.FindOne(ctx, bson.M{"_id": courierID, "role": models.RoleCourier}).Decode(&courier)
+	if err != nil {
+		return courier, fmt.Errorf("Kurir tidak ditemukan")
+	}
+	return courier, nil
+}
+
+// logisticsFee menjumlahkan ongkos jemput dan antar dari sebuah transaksi
+func logisticsFee(transaction models.Transaction) float64 {
+	var fee float64
+	if transaction.Pickup != nil {
+		fee += transaction.Pickup.Fee
+	}
+	if transaction.Delivery != nil {
+		fee += transaction.Delivery.Fee
+	}
+	return fee
+}
+
+// Fungsi untuk menugaskan kurir ke penjemputan atau pengantaran transaksi
+func AssignCourier(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "ID tidak disediakan", http.StatusBadRequest)
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Leg       string             `json:"leg"` // "pickup" atau "delivery"
+		CourierID primitive.ObjectID `json:"courierId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if req.Leg != models.LegPickup && req.Leg != models.LegDelivery {
+		http.Error(w, "Jenis tugas harus pickup atau delivery", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	courier, err := findCourier(ctx, req.CourierID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Hanya bisa ditugaskan jika transaksi memang memiliki tugas jemput/antar tersebut
+	filter := bson.M{"_id": objID, req.Leg: bson.M{"$ne": nil}}
+	update := bson.M{"$set": bson.M{
+		req.Leg + ".courierId":   courier.ID,
+		req.Leg + ".courierName": courier.Username,
+	}}
+	result, err := config.TransactionCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		http.Error(w, "Gagal menugaskan kurir", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "Transaksi tidak ditemukan atau tidak memiliki tugas "+req.Leg, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Kurir berhasil ditugaskan",
+		"id":          objID.Hex(),
+		"leg":         req.Leg,
+		"courierId":   courier.ID.Hex(),
+		"courierName": courier.Username,
+	})
+}
+
+// Model ringkas tugas kurir untuk ditampilkan di aplikasi kurir
+type courierJob struct {
+	TransactionID primitive.ObjectID `json:"transactionId"`
+	Leg           string             `json:"leg"`
+	CustomerName  string             `json:"customerName"`
+	CustomerPhone string             `json:"customerPhone"`
+	Address       string             `json:"address"`
+	Window        models.TimeSlot    `json:"window"`
+	Status        string             `json:"status"`
+	DoneAt        *time.Time         `json:"doneAt,omitempty"`
+}
+
+// Fungsi untuk mendapatkan daftar tugas jemput/antar milik kurir yang sedang login
+func GetCourierJobs(w http.ResponseWriter, r *http.Request) {
+	courierID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Pengguna tidak dikenali", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Secara bawaan hanya tugas yang belum selesai, ?all=true untuk menampilkan semuanya
+	showAll := r.URL.Query().Get("all") == "true"
+	pickupFilter := bson.M{"pickup.courierId": courierID}
+	deliveryFilter := bson.M{"delivery.courierId": courierID}
+	if !showAll {
+		pickupFilter["pickup.status"] = models.LogisticsScheduled
+		deliveryFilter["delivery.status"] = models.LogisticsScheduled
+	}
+
+	transactions, err := findTransactions(ctx, bson.M{"$or": []bson.M{pickupFilter, deliveryFilter}})
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan tugas kurir", http.StatusInternalServerError)
+		return
+	}
+
+	jobs := []courierJob{}
+	for _, transaction := range transactions {
+		legs := map[string]*models.Logistics{
+			models.LegPickup:   transaction.Pickup,
+			models.LegDelivery: transaction.Delivery,
+		}
+		for _, leg := range []string{models.LegPickup, models.LegDelivery} {
+			logistics := legs[leg]
+			if logistics == nil || logistics.CourierID != courierID {
+				continue
+			}
+			if !showAll && logistics.Status != models.LogisticsScheduled {
+				continue
+			}
+			jobs = append(jobs, courierJob{
+				TransactionID: transaction.ID,
+				Leg:           leg,
+				CustomerName:  transaction.Customer.FullName,
+				CustomerPhone: transaction.Customer.PhoneNumber,
+				Address:       logistics.Address,
+				Window:        logistics.Window,
+				Status:        logistics.Status,
+				DoneAt:        logistics.DoneAt,
+			})
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Window.Start.Before(jobs[j].Window.Start) })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// Fungsi untuk menandai penjemputan/pengantaran selesai oleh kurir
+func CompleteCourierJob(w http.ResponseWriter, r *http.Request) {
+	courierID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Pengguna tidak dikenali", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		TransactionID primitive.ObjectID `json:"transactionId"`
+		Leg           string             `json:"leg"`
+		ProofNote     string             `json:"proofNote"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if req.Leg != models.LegPickup && req.Leg != models.LegDelivery {
+		http.Error(w, "Jenis tugas harus pickup atau delivery", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Admin boleh menandai tugas kurir mana pun, kurir hanya tugasnya sendiri
+	filter := bson.M{
+		"_id":               req.TransactionID,
+		req.Leg + ".status": models.LogisticsScheduled,
+	}
+	if claims, _ := utils.ClaimsFromContext(r.Context()); claims.Role != models.RoleAdmin {
+		filter[req.Leg+".courierId"] = courierID
+	}
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{
+		req.Leg + ".status":    models.LogisticsDone,
+		req.Leg + ".doneAt":    now,
+		req.Leg + ".proofNote": req.ProofNote,
+	}}
+	result, err := config.TransactionCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		http.Error(w, "Gagal memperbarui tugas kurir", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "Tugas tidak ditemukan atau sudah selesai", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Tugas kurir berhasil diselesaikan",
+		"leg":     req.Leg,
+		"doneAt":  now,
+	})
+}
+
+// currentUserID mengambil ID user yang sedang login dari klaim JWT
+func currentUserID(r *http.Request) (primitive.ObjectID, bool) {
+	claims, ok := utils.ClaimsFromContext(r.Context())
+	if !ok {
+		return primitive.NilObjectID, false
+	}
+	id, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
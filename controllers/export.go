@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/utils"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// exportSheet membaca parameter format (csv/xlsx) dan locale (id/en), menyiapkan header unduhan,
+// lalu mengembalikan penulis tabel yang langsung menulis ke response
+func exportSheet(w http.ResponseWriter, r *http.Request, name string, loc *time.Location) (utils.SheetWriter, error) {
+	query := r.URL.Query()
+	locale, ok := utils.ExportLocaleFor(query.Get("locale"))
+	if !ok {
+		return nil, fmt.Errorf("locale harus id atau en")
+	}
+
+	filename := fmt.Sprintf("%s-%s", name, time.Now().In(loc).Format("20060102-1504"))
+	switch query.Get("format") {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+		return utils.NewCSVSheet(w, locale, loc)
+	case "xlsx":
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, filename))
+		return utils.NewXLSXSheet(w, name, locale, loc)
+	default:
+		return nil, fmt.Errorf("format harus csv atau xlsx")
+	}
+}
+
+// exportDateFilter membuat filter rentang tanggal dari parameter from/to, kosong jika keduanya tidak diisi
+func exportDateFilter(r *http.Request, loc *time.Location) (bson.M, error) {
+	query := r.URL.Query()
+	if query.Get("from") == "" && query.Get("to") == "" {
+		return nil, nil
+	}
+	rng, err := parseReportRange(r, loc, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if query.Get("from") == "" {
+		// Hanya batas akhir yang diisi: ekspor semua data sampai tanggal tersebut
+		return bson.M{"$lt": rng.End}, nil
+	}
+	return bson.M{"$gte": rng.From, "$lt": rng.End}, nil
+}
+
+// streamRows menulis setiap dokumen dari cursor ke sheet. Response sudah terkirim sebagian,
+// sehingga kesalahan di tengah jalan hanya bisa dicatat ke log.
+func streamRows(ctx context.Context, cursor *mongo.Cursor, sheet utils.SheetWriter, writeDocument func(*mongo.Cursor) error) {
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		if err := writeDocument(cursor); err != nil {
+			log.Println("Ekspor terhenti: ", err)
+			return
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Println("Ekspor terhenti: ", err)
+		return
+	}
+	if err := sheet.Close(); err != nil {
+		log.Println("Gagal menutup file ekspor: ", err)
+	}
+}
+
+// Fungsi untuk mengekspor transaksi, satu baris per item cucian.
+// Filter: from, to, status, paymentStatus, customerId. Format: format=csv|xlsx, locale=id|en.
+func ExportTransactions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	loc := utils.ShopLocation(loadShopSettings(ctx))
+	query := r.URL.Query()
+	filter := bson.M{}
+	dateFilter, err := exportDateFilter(r, loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if dateFilter != nil {
+		filter["transactionDate"] = dateFilter
+	}
+	if status := query.Get("status"); status != "" {
+		filter["status"] = status
+	}
+	if paymentStatus := query.Get("paymentStatus"); paymentStatus != "" {
+		filter["paymentStatus"] = paymentStatus
+	}
+	if id := query.Get("customerId"); id != "" {
+		customerID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			http.Error(w, "ID customer tidak valid", http.StatusBadRequest)
+			return
+		}
+		filter["customerId"] = customerID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "transactionDate", Value: 1}}).SetProjection(bson.M{"items.pieces": 0})
+	cursor, err := config.TransactionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, "Gagal mengambil data transaksi", http.StatusInternalServerError)
+		return
+	}
+
+	sheet, err := exportSheet(w, r, "transaksi", loc)
+	if err != nil {
+		cursor.Close(ctx)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sheet.WriteHeader("No. Order", "Tanggal", "Customer", "No. Telepon", "Status", "Status Bayar",
+		"Item", "Layanan", "Kecepatan", "Jumlah", "Satuan", "Harga Satuan", "Total Item",
+		"Subtotal", "Ongkos Antar-Jemput", "Biaya Penyimpanan", "Total", "Dibayar", "Sisa", "Selesai")
+
+	streamRows(ctx, cursor, sheet, func(cursor *mongo.Cursor) error {
+		var transaction models.Transaction
+		if err := cursor.Decode(&transaction); err != nil {
+			return err
+		}
+		header := []interface{}{
+			transaction.OrderNumber, transaction.TransactionDate, transaction.Customer.FullName,
+			transaction.Customer.PhoneNumber, transaction.Status, transaction.PaymentStatus,
+		}
+		footer := []interface{}{
+			transaction.Subtotal, transaction.DeliveryFee, transaction.StorageFee, transaction.TotalAmount,
+			transaction.AmountPaid, outstandingBalance(transaction), transaction.CompletedAt,
+		}
+
+		// Transaksi tanpa item tetap ditulis satu baris agar totalnya ikut terekspor
+		if len(transaction.Items) == 0 {
+			row := append(append(header, nil, nil, nil, nil, nil, nil, nil), footer...)
+			return sheet.WriteRow(row...)
+		}
+		for i, item := range transaction.Items {
+			row := append([]interface{}{}, header...)
+			row = append(row, i+1, item.Service.ServiceName, item.SpeedTier, item.Quantity, item.Service.Unit, item.UnitPrice, item.TotalPrice)
+			row = append(row, footer...)
+			if err := sheet.WriteRow(row...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Fungsi untuk mengekspor pembayaran beserta nomor order transaksinya.
+// Filter: from, to, status, channel. Format: format=csv|xlsx, locale=id|en.
+func ExportPayments(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	loc := utils.ShopLocation(loadShopSettings(ctx))
+	query := r.URL.Query()
+	filter := bson.M{}
+	dateFilter, err := exportDateFilter(r, loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if dateFilter != nil {
+		filter["created_at"] = dateFilter
+	}
+	if status := query.Get("status"); status != "" {
+		filter["status"] = status
+	}
+	if channel := query.Get("channel"); channel != "" {
+		filter["channel"] = channel
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.M{"created_at": 1}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         config.TransactionCollection.Name(),
+			"localField":   "transactionId",
+			"foreignField": "_id",
+			"as":           "transaction",
+		}}},
+		{{Key: "$addFields", Value: bson.M{"orderNumber": bson.M{"$arrayElemAt": bson.A{"$transaction.orderNumber", 0}}}}},
+		{{Key: "$project", Value: bson.M{"transaction": 0}}},
+	}
+	cursor, err := config.PaymentCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		http.Error(w, "Gagal mengambil data pembayaran", http.StatusInternalServerError)
+		return
+	}
+
+	sheet, err := exportSheet(w, r, "pembayaran", loc)
+	if err != nil {
+		cursor.Close(ctx)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sheet.WriteHeader("Tanggal", "Order ID", "No. Order", "Saluran", "Metode", "Status",
+		"Jumlah", "Refund", "Bersih", "Uang Diterima", "Kembalian", "No. Referensi", "Bank VA", "No. VA", "Catatan")
+
+	streamRows(ctx, cursor, sheet, func(cursor *mongo.Cursor) error {
+		var payment struct {
+			models.Payment `bson:",inline"`
+			OrderNumber    string `bson:"orderNumber"`
+		}
+		if err := cursor.Decode(&payment); err != nil {
+			return err
+		}
+		channel := payment.Channel
+		if channel == "" {
+			channel = models.PaymentChannelMidtrans
+		}
+		net := 0.0
+		if isCollectedStatus(payment.Status) {
+			net = payment.GrossAmount - payment.RefundedAmount
+		}
+		return sheet.WriteRow(payment.CreatedAt, payment.OrderID, payment.OrderNumber, channel, payment.PaymentMethod,
+			payment.Status, payment.GrossAmount, payment.RefundedAmount, net, payment.AmountTendered, payment.ChangeGiven,
+			payment.ReferenceNumber, payment.VABank, payment.VANumber, payment.Note)
+	})
+}
+
+// Fungsi untuk mengekspor data customer, parameter q opsional untuk mencari nama atau nomor telepon
+func ExportCustomers(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	loc := utils.ShopLocation(loadShopSettings(ctx))
+	filter := bson.M{}
+	if q := r.URL.Query().Get("q"); q != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(q), Options: "i"}
+		filter["$or"] = []bson.M{{"fullName": pattern}, {"phoneNumber": pattern}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "fullName", Value: 1}})
+	cursor, err := config.CustomerCollection.Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, "Gagal mengambil data customer", http.StatusInternalServerError)
+		return
+	}
+
+	sheet, err := exportSheet(w, r, "customer", loc)
+	if err != nil {
+		cursor.Close(ctx)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sheet.WriteHeader("ID", "Nama", "No. Telepon", "Email", "Bahasa", "Notifikasi")
+
+	streamRows(ctx, cursor, sheet, func(cursor *mongo.Cursor) error {
+		var customer models.Customer
+		if err := cursor.Decode(&customer); err != nil {
+			return err
+		}
+		return sheet.WriteRow(customer.ID.Hex(), customer.FullName, customer.PhoneNumber, customer.Email,
+			customer.Language, customer.NotifyVia)
+	})
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// errTagCodeInUse menandakan kode tag dari request sudah dipakai potongan lain
+var errTagCodeInUse = errors.New("Kode tag sudah dipakai")
+
+// assignTagCodes memberi kode tag pada setiap potongan cucian yang belum memilikinya.
+// Kode yang diisi kasir diperiksa keunikannya terhadap transaksi lain, transactionID sendiri dikecualikan
+// agar transaksi yang diedit boleh mengirim ulang kode miliknya.
+func assignTagCodes(ctx context.Context, transactionID primitive.ObjectID, items []models.TransactionItem) error {
+	used := map[string]bool{}
+	for i := range items {
+		item := &items[i]
+		if len(item.Pieces) == 0 {
+			continue
+		}
+
+		// Untuk layanan per potong, jumlah tag harus sama dengan quantity
+		if item.Service.Unit != "kg" && len(item.Pieces) != item.Quantity {
+			return fmt.Errorf("Jumlah potongan (%d) tidak sama dengan quantity (%d) untuk layanan %s",
+				len(item.Pieces), item.Quantity, item.Service.ServiceName)
+		}
+
+		for j := range item.Pieces {
+			piece := &item.Pieces[j]
+			if piece.TagCode != "" {
+				piece.TagCode = strings.ToUpper(strings.TrimSpace(piece.TagCode))
+				if used[piece.TagCode] {
+					return fmt.Errorf("%w: %s", errTagCodeInUse, piece.TagCode)
+				}
+				count, err := config.TransactionCollection.CountDocuments(ctx, bson.M{
+					"_id":                  bson.M{"$ne": transactionID},
+					"items.pieces.tagCode": piece.TagCode,
+				})
+				if err != nil {
+					return fmt.Errorf("Gagal memeriksa kode tag")
+				}
+				if count > 0 {
+					return fmt.Errorf("%w: %s", errTagCodeInUse, piece.TagCode)
+				}
+				used[piece.TagCode] = true
+				continue
+			}
+
+			code, err := newTagCode(ctx, used)
+			if err != nil {
+				return err
+			}
+			piece.TagCode = code
+			used[code] = true
+		}
+	}
+	return nil
+}
+
+// tagCodesOf mengumpulkan kode tag semua potongan. Kode disimpan juga di field tagCodes karena index unik
+// pada items.pieces.tagCode ikut mengindeks null untuk item tanpa potongan sehingga transaksi biasa saling bentrok.
+func tagCodesOf(items []models.TransactionItem) []string {
+	codes := []string{}
+	for _, item := range items {
+		for _, piece := range item.Pieces {
+			codes = append(codes, piece.TagCode)
+		}
+	}
+	return codes
+}
+
+// cloneItems menyalin item beserta potongannya, dipakai untuk mengulang pemberian kode tag
+func cloneItems(items []models.TransactionItem) []models.TransactionItem {
+	clone := append([]models.TransactionItem{}, items...)
+	for i := range clone {
+		if clone[i].Pieces != nil {
+			clone[i].Pieces = append([]models.GarmentPiece{}, clone[i].Pieces...)
+		}
+	}
+	return clone
+}
+
+// isTagCodeConflict memeriksa apakah kegagalan simpan berasal dari index unik kode tag
+func isTagCodeConflict(err error) bool {
+	return mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "tagCodes")
+}
+
+// reassignTagCodes dipakai saat transaksi lain menyimpan kode tag yang sama lebih dulu. Item dikembalikan ke
+// isian kasir, kode otomatis dibuat ulang, dan kode dari kasir diperiksa lagi sehingga bentrokannya menjadi 409.
+func reassignTagCodes(ctx context.Context, transactionID primitive.ObjectID, transaction *models.Transaction, original []models.TransactionItem) error {
+	transaction.Items = cloneItems(original)
+	if err := assignTagCodes(ctx, transactionID, transaction.Items); err != nil {
+		return err
+	}
+	transaction.TagCodes = tagCodesOf(transaction.Items)
+	return nil
+}
+
+// tagCodeErrorStatus memilih status HTTP untuk kesalahan dari assignTagCodes
+func tagCodeErrorStatus(err error) int {
+	if errors.Is(err, errTagCodeInUse) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// newTagCode membuat kode tag baru yang belum dipakai transaksi lain
+func newTagCode(ctx context.Context, used map[string]bool) (string, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		code := "TG-" + utils.RandomCode(6)
+		if used[code] {
+			continue
+		}
+		count, err := config.TransactionCollection.CountDocuments(ctx, bson.M{"items.pieces.tagCode": code})
+		if err != nil {
+			return "", fmt.Errorf("Gagal memeriksa kode tag")
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("Gagal membuat kode tag unik")
+}
+
+// Fungsi untuk mencari transaksi pemilik potongan cucian berdasarkan kode tag
+func GetGarmentByTag(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	if code == "" {
+		http.Error(w, "Kode tag tidak disediakan", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var transaction models.Transaction
+	err := config.TransactionCollection.FindOne(ctx, bson.M{"items.pieces.tagCode": code}).Decode(&transaction)
+	if err != nil {
+		http.Error(w, "Kode tag tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	var customer models.Customer
+	err = config.CustomerCollection.FindOne(ctx, bson.M{"_id": transaction.CustomerID}).Decode(&customer)
+	if err == nil {
+		transaction.Customer = customer
+	}
+
+	for _, item := range transaction.Items {
+		for _, piece := range item.Pieces {
+			if piece.TagCode != code {
+				continue
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"piece":       piece,
+				"itemId":      item.ID.Hex(),
+				"serviceName": item.Service.ServiceName,
+				"transaction": transaction,
+			})
+			return
+		}
+	}
+
+	http.Error(w, "Kode tag tidak ditemukan", http.StatusNotFound)
+}
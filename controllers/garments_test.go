@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"laundry-pos/models"
+)
+
+func TestTagCodesOf(t *testing.T) {
+	items := []models.TransactionItem{
+		{Quantity: 3},
+		{Pieces: []models.GarmentPiece{{TagCode: "TG-AAA111"}, {TagCode: "TG-BBB222"}}},
+		{Pieces: []models.GarmentPiece{{TagCode: "JAS-01"}}},
+	}
+	want := []string{"TG-AAA111", "TG-BBB222", "JAS-01"}
+	if got := tagCodesOf(items); !reflect.DeepEqual(got, want) {
+		t.Errorf("tagCodesOf = %v, want %v", got, want)
+	}
+	if got := tagCodesOf([]models.TransactionItem{{Quantity: 2}}); len(got) != 0 {
+		t.Errorf("transaksi tanpa potongan seharusnya tanpa kode tag, got %v", got)
+	}
+}
+
+func TestCloneItemsKeepsOriginalPieces(t *testing.T) {
+	original := []models.TransactionItem{{Pieces: []models.GarmentPiece{{TagCode: ""}, {TagCode: "JAS-01"}}}}
+	clone := cloneItems(original)
+	clone[0].Pieces[0].TagCode = "TG-AAA111"
+
+	// Kode otomatis pada salinan tidak boleh terbawa ke isian asli agar bisa dibuat ulang
+	if original[0].Pieces[0].TagCode != "" {
+		t.Errorf("isian asli ikut berubah: %+v", original[0].Pieces)
+	}
+}
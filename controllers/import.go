@@ -0,0 +1,443 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/services"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Batas ukuran file impor
+const importMaxBytes = 5 << 20
+
+// Cara menangani baris yang sudah ada di database
+const (
+	ImportSkip   = "skip"   // Biarkan data lama
+	ImportUpdate = "update" // Timpa data lama dengan kolom yang diisi di CSV
+)
+
+// Hasil per baris impor
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionSkip   = "skip"
+	ImportActionError  = "error"
+)
+
+// ImportRowResult adalah hasil validasi dan penyimpanan satu baris CSV
+type ImportRowResult struct {
+	Row    int      `json:"row"` // Nomor baris di file, header adalah baris 1
+	Key    string   `json:"key"` // Nomor telepon customer atau nama layanan
+	Action string   `json:"action"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ImportReport adalah ringkasan impor CSV
+type ImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Mode    string            `json:"mode"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+func (report *ImportReport) add(row ImportRowResult) {
+	report.Total++
+	switch row.Action {
+	case ImportActionCreate:
+		report.Created++
+	case ImportActionUpdate:
+		report.Updated++
+	case ImportActionSkip:
+		report.Skipped++
+	default:
+		report.Failed++
+	}
+	report.Rows = append(report.Rows, row)
+}
+
+// importCSV adalah isi file CSV yang sudah dibaca: kolom dipetakan ke nama field berdasarkan header
+type importCSV struct {
+	columns map[string]int
+	records [][]string
+}
+
+// get mengambil nilai kolom pada sebuah baris, kosong jika kolom tidak ada
+func (c importCSV) get(record []string, field string) string {
+	i, ok := c.columns[field]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// has memeriksa apakah kolom ada di header, dipakai agar mode update tidak mengosongkan kolom yang tidak diimpor
+func (c importCSV) has(field string) bool {
+	_, ok := c.columns[field]
+	return ok
+}
+
+// readImportCSV membaca file dari form multipart "file" atau langsung dari body request.
+// Pemisah koma atau titik koma dideteksi dari header, nama kolom dicocokkan dengan aliases.
+func readImportCSV(w http.ResponseWriter, r *http.Request, aliases map[string]string, required ...string) (importCSV, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxBytes)
+	var source io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(importMaxBytes); err != nil {
+			return importCSV{}, fmt.Errorf("File terlalu besar atau form tidak valid")
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return importCSV{}, fmt.Errorf("File CSV tidak ditemukan di field file")
+		}
+		defer file.Close()
+		source = file
+	}
+
+	content, err := io.ReadAll(source)
+	if err != nil {
+		return importCSV{}, fmt.Errorf("File terlalu besar atau tidak bisa dibaca")
+	}
+	text := strings.TrimPrefix(string(content), "\ufeff")
+	firstLine := text
+	if i := strings.IndexAny(text, "\r\n"); i >= 0 {
+		firstLine = text[:i]
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return importCSV{}, fmt.Errorf("CSV tidak valid: %v", err)
+	}
+	if len(records) == 0 {
+		return importCSV{}, fmt.Errorf("File CSV kosong")
+	}
+
+	parsed := importCSV{columns: map[string]int{}, records: records[1:]}
+	for i, header := range records[0] {
+		if field, ok := aliases[strings.ToLower(strings.TrimSpace(header))]; ok {
+			parsed.columns[field] = i
+		}
+	}
+	for _, field := range required {
+		if !parsed.has(field) {
+			return importCSV{}, fmt.Errorf("Kolom %s wajib ada di header CSV", field)
+		}
+	}
+	return parsed, nil
+}
+
+// importOptions membaca parameter mode (skip/update) dan dryRun
+func importOptions(r *http.Request) (string, bool, error) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = ImportSkip
+	}
+	if mode != ImportSkip && mode != ImportUpdate {
+		return "", false, fmt.Errorf("mode harus skip atau update")
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	return mode, dryRun, nil
+}
+
+// Nilai angka ribuan bergaya Indonesia (15.000, 1.250.000) maupun Inggris (15,000, 1,250,000).
+// Harga dalam rupiah tidak memiliki tiga angka desimal, sehingga pola ini selalu dibaca sebagai ribuan.
+var thousandsPattern = regexp.MustCompile(`^\d{1,3}(\.\d{3})+$`)
+var thousandsCommaPattern = regexp.MustCompile(`^\d{1,3}(,\d{3})+$`)
+
+// Awalan mata uang yang dibuang, misalnya "Rp", "Rp.", atau "IDR"
+var currencyPrefixPattern = regexp.MustCompile(`^(?i)(rp\.?|idr)`)
+
+// parseImportNumber menerima angka bergaya Indonesia ("Rp 15.000", "2,5") maupun Inggris ("15,000", "2.5")
+func parseImportNumber(value string) (float64, error) {
+	value = currencyPrefixPattern.ReplaceAllString(strings.TrimSpace(value), "")
+	value = strings.ReplaceAll(value, " ", "")
+	switch {
+	case thousandsCommaPattern.MatchString(value):
+		value = strings.ReplaceAll(value, ",", "")
+	case strings.Contains(value, ",") && strings.Contains(value, "."):
+		// Pemisah yang terakhir adalah pemisah desimal
+		if strings.LastIndex(value, ",") > strings.LastIndex(value, ".") {
+			value = strings.ReplaceAll(strings.ReplaceAll(value, ".", ""), ",", ".")
+		} else {
+			value = strings.ReplaceAll(value, ",", "")
+		}
+	case strings.Contains(value, ","):
+		value = strings.ReplaceAll(value, ",", ".")
+	case thousandsPattern.MatchString(value):
+		value = strings.ReplaceAll(value, ".", "")
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// phoneKey menyamakan format nomor telepon (0812..., +62812..., 62-812...) untuk mencari duplikat
+func phoneKey(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	key := digits.String()
+	if strings.HasPrefix(key, "62") {
+		key = "0" + key[2:]
+	}
+	return key
+}
+
+// Nama kolom CSV customer yang dikenali, termasuk header dari ekspor customer
+var customerImportAliases = map[string]string{
+	"fullname": "fullName", "nama": "fullName", "name": "fullName", "nama lengkap": "fullName",
+	"phonenumber": "phoneNumber", "phone": "phoneNumber", "telepon": "phoneNumber", "no. telepon": "phoneNumber", "no telepon": "phoneNumber",
+	"hp": "phoneNumber", "no. hp": "phoneNumber", "no hp": "phoneNumber",
+	"email":    "email",
+	"language": "language", "bahasa": "language",
+	"notifyvia": "notifyVia", "notifikasi": "notifyVia",
+}
+
+// Fungsi untuk impor customer dari CSV. Duplikat dicari berdasarkan nomor telepon,
+// mode=skip (bawaan) melewatkannya dan mode=update menimpanya. dryRun=true hanya memvalidasi.
+func ImportCustomers(w http.ResponseWriter, r *http.Request) {
+	mode, dryRun, err := importOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, err := readImportCSV(w, r, customerImportAliases, "fullName", "phoneNumber")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Customer yang sudah ada dipetakan berdasarkan nomor telepon yang dinormalisasi
+	cursor, err := config.CustomerCollection.Find(ctx, bson.M{})
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan data customer", http.StatusInternalServerError)
+		return
+	}
+	var existing []models.Customer
+	if err := cursor.All(ctx, &existing); err != nil {
+		http.Error(w, "Gagal membaca data customer", http.StatusInternalServerError)
+		return
+	}
+	existingByPhone := map[string]primitive.ObjectID{}
+	for _, customer := range existing {
+		if key := phoneKey(customer.PhoneNumber); key != "" {
+			existingByPhone[key] = customer.ID
+		}
+	}
+
+	report := ImportReport{DryRun: dryRun, Mode: mode, Rows: []ImportRowResult{}}
+	seen := map[string]int{}
+	for i, record := range file.records {
+		customer := models.Customer{
+			FullName:    file.get(record, "fullName"),
+			PhoneNumber: file.get(record, "phoneNumber"),
+			Email:       file.get(record, "email"),
+			Language:    strings.ToLower(file.get(record, "language")),
+			NotifyVia:   strings.ToLower(file.get(record, "notifyVia")),
+		}
+		key := phoneKey(customer.PhoneNumber)
+		result := ImportRowResult{Row: i + 2, Key: customer.PhoneNumber}
+
+		if customer.FullName == "" {
+			result.Errors = append(result.Errors, "Nama wajib diisi")
+		}
+		if len(key) < 8 {
+			result.Errors = append(result.Errors, "Nomor telepon tidak valid")
+		}
+		if customer.Email != "" && !strings.Contains(customer.Email, "@") {
+			result.Errors = append(result.Errors, "Email tidak valid")
+		}
+		if customer.Language != "" && customer.Language != "id" && customer.Language != "en" {
+			result.Errors = append(result.Errors, "Bahasa harus id atau en")
+		}
+		switch customer.NotifyVia {
+		case "", "none", services.ChannelWhatsApp, services.ChannelSMS, services.ChannelEmail:
+		default:
+			result.Errors = append(result.Errors, "Notifikasi harus whatsapp, sms, email, atau none")
+		}
+		if row, ok := seen[key]; ok && key != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("Nomor telepon sama dengan baris %d", row))
+		}
+		if len(result.Errors) > 0 {
+			result.Action = ImportActionError
+			report.add(result)
+			continue
+		}
+		seen[key] = result.Row
+
+		id, exists := existingByPhone[key]
+		switch {
+		case !exists:
+			result.Action = ImportActionCreate
+			if !dryRun {
+				customer.ID = primitive.NewObjectID()
+				if _, err := config.CustomerCollection.InsertOne(ctx, customer); err != nil {
+					result.Action, result.Errors = ImportActionError, []string{"Gagal menyimpan customer"}
+				}
+			}
+		case mode == ImportSkip:
+			result.Action = ImportActionSkip
+		default:
+			result.Action = ImportActionUpdate
+			if !dryRun {
+				// Hanya kolom yang ada di file yang ditimpa
+				set := bson.M{"fullName": customer.FullName, "phoneNumber": customer.PhoneNumber}
+				if file.has("email") {
+					set["email"] = customer.Email
+				}
+				if file.has("language") {
+					set["language"] = customer.Language
+				}
+				if file.has("notifyVia") {
+					set["notifyVia"] = customer.NotifyVia
+				}
+				if _, err := config.CustomerCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set}); err != nil {
+					result.Action, result.Errors = ImportActionError, []string{"Gagal memperbarui customer"}
+				}
+			}
+		}
+		report.add(result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Nama kolom CSV layanan yang dikenali
+var serviceImportAliases = map[string]string{
+	"servicename": "serviceName", "layanan": "serviceName", "nama layanan": "serviceName", "nama": "serviceName", "name": "serviceName",
+	"description": "description", "deskripsi": "description", "keterangan": "description",
+	"unitprice": "unitPrice", "harga": "unitPrice", "harga satuan": "unitPrice", "price": "unitPrice",
+	"unit": "unit", "satuan": "unit",
+	"turnaroundhours": "turnaroundHours", "durasi": "turnaroundHours", "durasi (jam)": "turnaroundHours", "lama pengerjaan (jam)": "turnaroundHours",
+}
+
+// Fungsi untuk impor daftar harga layanan dari CSV. Duplikat dicari berdasarkan nama layanan (tanpa membedakan
+// huruf besar/kecil), mode=skip (bawaan) melewatkannya dan mode=update menimpanya. dryRun=true hanya memvalidasi.
+func ImportServices(w http.ResponseWriter, r *http.Request) {
+	mode, dryRun, err := importOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, err := readImportCSV(w, r, serviceImportAliases, "serviceName", "unitPrice", "unit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	cursor, err := config.ServiceCollection.Find(ctx, bson.M{})
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan layanan", http.StatusInternalServerError)
+		return
+	}
+	var existing []models.Service
+	if err := cursor.All(ctx, &existing); err != nil {
+		http.Error(w, "Gagal membaca layanan", http.StatusInternalServerError)
+		return
+	}
+	existingByName := map[string]primitive.ObjectID{}
+	for _, service := range existing {
+		existingByName[strings.ToLower(strings.TrimSpace(service.ServiceName))] = service.ID
+	}
+
+	report := ImportReport{DryRun: dryRun, Mode: mode, Rows: []ImportRowResult{}}
+	seen := map[string]int{}
+	for i, record := range file.records {
+		service := models.Service{
+			ServiceName: file.get(record, "serviceName"),
+			Description: file.get(record, "description"),
+			Unit:        file.get(record, "unit"),
+		}
+		key := strings.ToLower(service.ServiceName)
+		result := ImportRowResult{Row: i + 2, Key: service.ServiceName}
+
+		if service.ServiceName == "" {
+			result.Errors = append(result.Errors, "Nama layanan wajib diisi")
+		}
+		if price, err := parseImportNumber(file.get(record, "unitPrice")); err != nil || price <= 0 {
+			result.Errors = append(result.Errors, "Harga satuan harus angka lebih dari 0")
+		} else {
+			service.UnitPrice = price
+		}
+		if service.Unit == "" {
+			result.Errors = append(result.Errors, "Satuan wajib diisi")
+		}
+		if value := file.get(record, "turnaroundHours"); value != "" {
+			hours, err := strconv.Atoi(value)
+			if err != nil || hours < 0 {
+				result.Errors = append(result.Errors, "Durasi pengerjaan harus jumlah jam")
+			}
+			service.TurnaroundHours = hours
+		}
+		if row, ok := seen[key]; ok && key != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("Nama layanan sama dengan baris %d", row))
+		}
+		if len(result.Errors) > 0 {
+			result.Action = ImportActionError
+			report.add(result)
+			continue
+		}
+		seen[key] = result.Row
+
+		id, exists := existingByName[key]
+		switch {
+		case !exists:
+			result.Action = ImportActionCreate
+			if !dryRun {
+				service.ID = primitive.NewObjectID()
+				if _, err := config.ServiceCollection.InsertOne(ctx, service); err != nil {
+					result.Action, result.Errors = ImportActionError, []string{"Gagal menyimpan layanan"}
+				}
+			}
+		case mode == ImportSkip:
+			result.Action = ImportActionSkip
+		default:
+			result.Action = ImportActionUpdate
+			if !dryRun {
+				// Pilihan kecepatan tidak ada di CSV sehingga tidak ikut ditimpa
+				set := bson.M{"serviceName": service.ServiceName, "unitPrice": service.UnitPrice, "unit": service.Unit}
+				if file.has("description") {
+					set["description"] = service.Description
+				}
+				if file.has("turnaroundHours") {
+					set["turnaroundHours"] = service.TurnaroundHours
+				}
+				if _, err := config.ServiceCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set}); err != nil {
+					result.Action, result.Errors = ImportActionError, []string{"Gagal memperbarui layanan"}
+				}
+			}
+		}
+		report.add(result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import "testing"
+
+func TestParseImportNumber(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"15000", 15000},
+		{"15.000", 15000},
+		{"15,000", 15000},
+		{"Rp 15.000", 15000},
+		{"Rp. 15.000", 15000},
+		{"rp15000", 15000},
+		{"IDR 1,250,000", 1250000},
+		{"1.250.000", 1250000},
+		{"2,5", 2.5},
+		{"2.5", 2.5},
+		{"1.234,56", 1234.56},
+		{"1,234.56", 1234.56},
+		{" 7 000 ", 7000},
+	}
+
+	for _, tt := range tests {
+		got, err := parseImportNumber(tt.value)
+		if err != nil {
+			t.Errorf("parseImportNumber(%q) error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseImportNumber(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "abc", "Rp", "1,2,3"} {
+		if _, err := parseImportNumber(value); err == nil {
+			t.Errorf("parseImportNumber(%q) seharusnya error", value)
+		}
+	}
+}
+
+func TestPhoneKey(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+	}{
+		{"081234567890", "081234567890"},
+		{"+6281234567890", "081234567890"},
+		{"62-812-3456-7890", "081234567890"},
+		{"0812 3456 7890", "081234567890"},
+		{"(021) 555-1234", "0215551234"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := phoneKey(tt.phone); got != tt.want {
+			t.Errorf("phoneKey(%q) = %q, want %q", tt.phone, got, tt.want)
+		}
+	}
+}
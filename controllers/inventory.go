@@ -0,0 +1,341 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Filter barang aktif yang stoknya sudah mencapai batas pemesanan ulang
+var lowStockFilter = bson.M{
+	"archivedAt": bson.M{"$exists": false},
+	"$expr":      bson.M{"$lte": bson.A{"$stock", "$reorderLevel"}},
+}
+
+// validateInventoryItem memeriksa data barang, pesan kosong berarti valid
+func validateInventoryItem(item *models.InventoryItem) string {
+	item.Name = strings.TrimSpace(item.Name)
+	item.Unit = strings.TrimSpace(item.Unit)
+	if item.Name == "" || item.Unit == "" {
+		return "Nama dan satuan barang wajib diisi"
+	}
+	if item.ReorderLevel < 0 {
+		return "Batas stok minimum tidak boleh negatif"
+	}
+	return ""
+}
+
+// Fungsi untuk menambah barang inventory. Stok awal (jika ada) dicatat sebagai stok masuk.
+func CreateInventoryItem(w http.ResponseWriter, r *http.Request) {
+	var item models.InventoryItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if message := validateInventoryItem(&item); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+	if item.Stock < 0 {
+		http.Error(w, "Stok awal tidak boleh negatif", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	item.ID = primitive.NewObjectID()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	item.ArchivedAt = nil
+	if _, err := config.InventoryCollection.InsertOne(ctx, item); err != nil {
+		http.Error(w, "Gagal menambahkan barang", http.StatusInternalServerError)
+		return
+	}
+
+	if item.Stock > 0 {
+		userID, _ := currentUserID(r)
+		_, err := config.StockMovementCollection.InsertOne(ctx, models.StockMovement{
+			ItemID:     item.ID,
+			Kind:       models.StockIn,
+			Quantity:   item.Stock,
+			StockAfter: item.Stock,
+			Reason:     "Stok awal",
+			CreatedBy:  userID,
+			CreatedAt:  now,
+		})
+		// Stok tanpa riwayat tidak bisa diaudit, batalkan barang yang baru dibuat
+		if err != nil {
+			config.InventoryCollection.DeleteOne(ctx, bson.M{"_id": item.ID})
+			http.Error(w, "Gagal mencatat stok awal", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// Fungsi untuk mengambil daftar barang inventory, parameter category opsional.
+// Barang yang diarsipkan ikut ditampilkan jika archived=true.
+func GetInventoryItems(w http.ResponseWriter, r *http.Request) {
+	filter := bson.M{}
+	if r.URL.Query().Get("archived") != "true" {
+		filter["archivedAt"] = bson.M{"$exists": false}
+	}
+	if category := r.URL.Query().Get("category"); category != "" {
+		filter["category"] = category
+	}
+	listInventoryItems(w, filter)
+}
+
+// Fungsi untuk mengambil barang yang stoknya menipis (stok <= batas minimum)
+func GetLowStockItems(w http.ResponseWriter, r *http.Request) {
+	listInventoryItems(w, lowStockFilter)
+}
+
+func listInventoryItems(w http.ResponseWriter, filter bson.M) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "category", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := config.InventoryCollection.Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan inventory", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	items := []models.InventoryItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		http.Error(w, "Gagal membaca inventory", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// Fungsi untuk mengambil satu barang inventory berdasarkan ID
+func GetInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var item models.InventoryItem
+	err = config.InventoryCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&item)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "Barang tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan barang", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// Fungsi untuk memperbarui data barang. Stok tidak bisa diubah di sini, gunakan pergerakan stok.
+func UpdateInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	var item models.InventoryItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if message := validateInventoryItem(&item); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"name":         item.Name,
+		"category":     item.Category,
+		"unit":         item.Unit,
+		"reorderLevel": item.ReorderLevel,
+		"note":         item.Note,
+		"updatedAt":    time.Now(),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	filter := bson.M{"_id": id, "archivedAt": bson.M{"$exists": false}}
+	err = config.InventoryCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&item)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "Barang tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Gagal memperbarui barang", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// Fungsi untuk menghapus barang inventory. Barang hanya diarsipkan sehingga riwayat pergerakan stoknya tetap bisa diaudit.
+func DeleteInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"_id": id, "archivedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"archivedAt": now, "updatedAt": now}}
+	result, err := config.InventoryCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		http.Error(w, "Gagal menghapus barang", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "Barang tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    "Barang berhasil dihapus",
+		"deleted_id": id.Hex(),
+	})
+}
+
+// Fungsi untuk mencatat stok masuk (pembelian) atau keluar (pemakaian). Stok keluar ditolak jika stok tidak cukup.
+func RecordStockMovement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Pengguna tidak dikenali", http.StatusUnauthorized)
+		return
+	}
+
+	var movement models.StockMovement
+	if err := json.NewDecoder(r.Body).Decode(&movement); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if movement.Kind != models.StockIn && movement.Kind != models.StockOut {
+		http.Error(w, "Jenis harus in atau out", http.StatusBadRequest)
+		return
+	}
+	if movement.Quantity <= 0 {
+		http.Error(w, "Jumlah harus lebih dari 0", http.StatusBadRequest)
+		return
+	}
+	movement.Reason = strings.TrimSpace(movement.Reason)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Stok diubah secara atomik, stok keluar hanya berhasil jika stok masih mencukupi
+	filter := bson.M{"_id": movement.ItemID, "archivedAt": bson.M{"$exists": false}}
+	change := movement.Quantity
+	if movement.Kind == models.StockOut {
+		filter["stock"] = bson.M{"$gte": movement.Quantity}
+		change = -movement.Quantity
+	}
+	now := time.Now()
+	var item models.InventoryItem
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$inc": bson.M{"stock": change}, "$set": bson.M{"updatedAt": now}}
+	err := config.InventoryCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&item)
+	if err == mongo.ErrNoDocuments {
+		count, _ := config.InventoryCollection.CountDocuments(ctx, bson.M{"_id": movement.ItemID, "archivedAt": bson.M{"$exists": false}})
+		if count == 0 {
+			http.Error(w, "Barang tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Stok tidak cukup", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Gagal memperbarui stok", http.StatusInternalServerError)
+		return
+	}
+
+	movement.ID = primitive.NilObjectID
+	movement.StockAfter = item.Stock
+	movement.CreatedBy = userID
+	movement.CreatedAt = now
+	result, err := config.StockMovementCollection.InsertOne(ctx, movement)
+	if err != nil {
+		http.Error(w, "Stok diperbarui tetapi riwayat gagal dicatat", http.StatusInternalServerError)
+		return
+	}
+	movement.ID = result.InsertedID.(primitive.ObjectID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"movement": movement,
+		"item":     item,
+		"lowStock": item.Stock <= item.ReorderLevel,
+	})
+}
+
+// Fungsi untuk melihat riwayat pergerakan stok, bisa difilter dengan itemId dan dibatasi dengan limit
+func GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := bson.M{}
+	if id := query.Get("itemId"); id != "" {
+		itemID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			http.Error(w, "ID barang tidak valid", http.StatusBadRequest)
+			return
+		}
+		filter["itemId"] = itemID
+	}
+	limit := int64(200)
+	if value, err := strconv.ParseInt(query.Get("limit"), 10, 64); err == nil && value > 0 && value < limit {
+		limit = value
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(limit)
+	cursor, err := config.StockMovementCollection.Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan riwayat stok", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	movements := []models.StockMovement{}
+	if err := cursor.All(ctx, &movements); err != nil {
+		http.Error(w, "Gagal membaca riwayat stok", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/services"
+	"laundry-pos/utils"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Input untuk mencatat pembayaran tunai/transfer/EDC/QRIS statis di kasir
+type recordPaymentRequest struct {
+	TransactionID   primitive.ObjectID `json:"transactionId"`
+	Channel         string             `json:"channel"`
+	Amount          float64            `json:"amount"`          // Kosong berarti seluruh sisa tagihan
+	AmountTendered  float64            `json:"amountTendered"`  // Hanya untuk tunai, kosong berarti uang pas
+	ReferenceNumber string             `json:"referenceNumber"` // Wajib untuk non-tunai
+	Note            string             `json:"note"`
+}
+
+// manualPaymentChannels adalah saluran yang boleh dicatat langsung oleh kasir
+var manualPaymentChannels = map[string]bool{
+	models.PaymentChannelCash:     true,
+	models.PaymentChannelTransfer: true,
+	models.PaymentChannelEDC:      true,
+	models.PaymentChannelQRIS:     true,
+}
+
+// Fungsi untuk mencatat pembayaran manual (tunai, transfer, EDC, QRIS statis) pada transaksi
+func RecordPayment(w http.ResponseWriter, r *http.Request) {
+	var req recordPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if req.TransactionID.IsZero() {
+		http.Error(w, "Transaction ID tidak disediakan", http.StatusBadRequest)
+		return
+	}
+	req.Channel = strings.ToLower(strings.TrimSpace(req.Channel))
+	if !manualPaymentChannels[req.Channel] {
+		http.Error(w, "Saluran pembayaran harus cash, transfer, edc, atau qris", http.StatusBadRequest)
+		return
+	}
+	req.ReferenceNumber = strings.TrimSpace(req.ReferenceNumber)
+	if req.Channel != models.PaymentChannelCash && req.ReferenceNumber == "" {
+		http.Error(w, "Nomor referensi wajib diisi untuk pembayaran non-tunai", http.StatusBadRequest)
+		return
+	}
+	if req.Amount < 0 || req.AmountTendered < 0 {
+		http.Error(w, "Jumlah pembayaran tidak boleh negatif", http.StatusBadRequest)
+		return
+	}
+
+	// Waktu lebih panjang karena tagihan online yang masih aktif dibatalkan lewat gateway
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	transaction, err := refreshPaymentStatus(ctx, req.TransactionID)
+	if err != nil {
+		http.Error(w, "Transaksi tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	outstanding := outstandingBalance(transaction)
+	if outstanding <= 0 {
+		http.Error(w, "Transaksi sudah lunas", http.StatusBadRequest)
+		return
+	}
+
+	amount := math.Round(req.Amount)
+	if amount <= 0 {
+		amount = outstanding
+	}
+	if amount > outstanding {
+		http.Error(w, fmt.Sprintf("Jumlah pembayaran melebihi sisa tagihan %s", utils.FormatRupiah(outstanding)), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	payment := models.Payment{
+		TransactionID:   transaction.ID,
+		OrderID:         manualPaymentOrderID(transaction, req.Channel),
+		GrossAmount:     amount,
+		Status:          "settlement",
+		CreatedAt:       now,
+		SettledAt:       &now,
+		PaymentMethod:   req.Channel,
+		Channel:         req.Channel,
+		ReferenceNumber: req.ReferenceNumber,
+		Note:            req.Note,
+	}
+	if cashierID, ok := currentUserID(r); ok {
+		payment.CashierID = cashierID
+		payment.ShiftID = openShiftID(ctx, cashierID)
+	}
+	// Uang tunai harus masuk ke laci shift yang sedang berjalan
+	if req.Channel == models.PaymentChannelCash && payment.ShiftID.IsZero() {
+		http.Error(w, "Buka shift terlebih dahulu sebelum menerima pembayaran tunai", http.StatusBadRequest)
+		return
+	}
+
+	// Kembalian hanya ada pada pembayaran tunai
+	if req.Channel == models.PaymentChannelCash {
+		tendered := math.Round(req.AmountTendered)
+		if tendered == 0 {
+			tendered = amount
+		}
+		if tendered < amount {
+			http.Error(w, "Uang yang diterima kurang dari jumlah pembayaran", http.StatusBadRequest)
+			return
+		}
+		payment.AmountTendered = tendered
+		payment.ChangeGiven = tendered - amount
+	}
+
+	// Customer memilih bayar di kasir, tutup link/QRIS/VA yang masih aktif agar tidak ikut dibayar.
+	// Dilakukan setelah semua validasi agar request yang ditolak tidak mematikan link pembayaran customer.
+	if err := cancelOpenCharges(ctx, transaction.ID, now); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	result, err := config.PaymentCollection.InsertOne(ctx, payment)
+	if err != nil {
+		http.Error(w, "Gagal menyimpan pembayaran", http.StatusInternalServerError)
+		return
+	}
+	payment.ID = result.InsertedID.(primitive.ObjectID)
+
+	transaction, err = refreshPaymentStatus(ctx, transaction.ID)
+	if err != nil {
+		http.Error(w, "Gagal memperbarui status pelunasan", http.StatusInternalServerError)
+		return
+	}
+	queueNotification(ctx, transaction, services.EventPaymentReceived, services.NotificationData{
+		Amount: utils.FormatRupiah(payment.GrossAmount),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Pembayaran berhasil dicatat",
+		"payment":       payment,
+		"amountPaid":    transaction.AmountPaid,
+		"outstanding":   outstandingBalance(transaction),
+		"paymentStatus": transaction.PaymentStatus,
+	})
+}
+
+// manualPaymentOrderID membuat order_id unik untuk pembayaran manual, misalnya LDR-20261018-0042-CASH-1A2B3C
+func manualPaymentOrderID(transaction models.Transaction, channel string) string {
+	prefix := transaction.OrderNumber
+	if prefix == "" {
+		prefix = transaction.ID.Hex()
+	}
+	return prefix + "-" + strings.ToUpper(channel) + "-" + utils.RandomCode(6)
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/services"
+	"laundry-pos/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Batas percobaan kirim sebelum notifikasi ditandai gagal
+const maxNotificationAttempts = 5
+
+// Batas waktu satu kali pengiriman, dan selama itu notifikasi dikunci agar tidak dikirim dua kali
+const notificationSendTimeout = 30 * time.Second
+
+// queueNotification menyimpan notifikasi ke outbox lalu mencoba mengirimnya di background, sehingga
+// provider WhatsApp/SMS/email yang lambat tidak menahan request transaksi, pembayaran, atau webhook.
+// Jika pengiriman background terhenti, notifikasi tetap pending dan dikirim oleh cron outbox.
+// data berisi isian khusus kejadian (nominal, lama menunggu), sisanya diisi dari transaksi.
+// Kegagalan hanya dicatat agar tidak menggagalkan proses transaksi/pembayaran.
+func queueNotification(ctx context.Context, transaction models.Transaction, event string, data services.NotificationData) {
+	var customer models.Customer
+	if err := config.CustomerCollection.FindOne(ctx, bson.M{"_id": transaction.CustomerID}).Decode(&customer); err != nil {
+		log.Printf("Notifikasi %s dilewati: customer %s tidak ditemukan", event, transaction.CustomerID.Hex())
+		return
+	}
+
+	channel, recipient := notificationTarget(customer)
+	if channel == "" {
+		return
+	}
+
+	settings := loadShopSettings(ctx)
+	data.CustomerName = customer.FullName
+	data.ShopName = settings.ShopName
+	data.OrderNumber = transaction.OrderNumber
+	data.TotalAmount = utils.FormatRupiah(transaction.TotalAmount)
+	data.TrackingCode = transaction.TrackingCode
+	if !transaction.EstimatedCompletion.IsZero() {
+		data.EstimatedCompletion = transaction.EstimatedCompletion.In(utils.ShopLocation(settings)).Format("02/01/2006 15:04")
+	}
+
+	subject, body, err := services.RenderNotification(event, customer.Language, data)
+	if err != nil {
+		log.Printf("Gagal menyusun notifikasi %s: %v", event, err)
+		return
+	}
+
+	now := time.Now()
+	notification := models.Notification{
+		TransactionID: transaction.ID,
+		CustomerID:    customer.ID,
+		Event:         event,
+		Channel:       channel,
+		Recipient:     recipient,
+		Subject:       subject,
+		Body:          body,
+		Status:        models.NotificationPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	result, err := config.NotificationCollection.InsertOne(ctx, notification)
+	if err != nil {
+		log.Printf("Gagal menyimpan notifikasi %s: %v", event, err)
+		return
+	}
+	notification.ID = result.InsertedID.(primitive.ObjectID)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationSendTimeout)
+		defer cancel()
+		deliverNotification(ctx, &notification)
+	}()
+}
+
+// notificationTarget memilih kanal dan tujuan sesuai preferensi customer, default WhatsApp
+func notificationTarget(customer models.Customer) (string, string) {
+	switch customer.NotifyVia {
+	case "none":
+		return "", ""
+	case services.ChannelEmail:
+		if customer.Email != "" {
+			return services.ChannelEmail, customer.Email
+		}
+	case services.ChannelSMS:
+		if customer.PhoneNumber != "" {
+			return services.ChannelSMS, customer.PhoneNumber
+		}
+	}
+
+	if customer.PhoneNumber != "" {
+		return services.ChannelWhatsApp, customer.PhoneNumber
+	}
+	if customer.Email != "" {
+		return services.ChannelEmail, customer.Email
+	}
+	return "", ""
+}
+
+// deliverNotification mengirim satu notifikasi dan mencatat hasilnya di outbox.
+// Hasilnya false jika notifikasi sedang dikirim proses lain atau belum waktunya dicoba lagi.
+func deliverNotification(ctx context.Context, notification *models.Notification) bool {
+	// Kunci notifikasi dengan memundurkan jadwal percobaan selama waktu kirim
+	now := time.Now()
+	claim := bson.M{"_id": notification.ID, "status": models.NotificationPending, "nextAttemptAt": bson.M{"$lte": now}}
+	lease := bson.M{"$set": bson.M{"nextAttemptAt": now.Add(notificationSendTimeout)}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := config.NotificationCollection.FindOneAndUpdate(ctx, claim, lease, opts).Decode(notification); err != nil {
+		return false
+	}
+
+	err := sendNotification(ctx, *notification)
+
+	notification.Attempts++
+	set := bson.M{"attempts": notification.Attempts}
+	if err == nil {
+		notification.Status = models.NotificationSent
+		notification.SentAt = &now
+		set["status"] = notification.Status
+		set["sentAt"] = now
+	} else {
+		// Jeda percobaan berikutnya makin lama: 1, 4, 9, 16 menit
+		notification.LastError = err.Error()
+		notification.NextAttemptAt = now.Add(time.Duration(notification.Attempts*notification.Attempts) * time.Minute)
+		if notification.Attempts >= maxNotificationAttempts {
+			notification.Status = models.NotificationFailed
+		}
+		set["status"] = notification.Status
+		set["lastError"] = notification.LastError
+		set["nextAttemptAt"] = notification.NextAttemptAt
+	}
+
+	if _, err := config.NotificationCollection.UpdateOne(ctx, bson.M{"_id": notification.ID}, bson.M{"$set": set}); err != nil {
+		log.Printf("Gagal memperbarui status notifikasi %s: %v", notification.ID.Hex(), err)
+	}
+	return true
+}
+
+func sendNotification(ctx context.Context, notification models.Notification) error {
+	notifier, err := services.NewNotifier(notification.Channel)
+	if err != nil {
+		return err
+	}
+	return notifier.Send(ctx, services.Message{
+		To:      notification.Recipient,
+		Subject: notification.Subject,
+		Body:    notification.Body,
+	})
+}
+
+// Ringkasan hasil pemrosesan outbox
+type outboxResult struct {
+	Processed int `json:"processed"`
+	Sent      int `json:"sent"`
+	Failed    int `json:"failed"`
+}
+
+// processOutbox mengirim ulang notifikasi yang masih pending dan sudah waktunya dicoba lagi
+func processOutbox(ctx context.Context, limit int64) (outboxResult, error) {
+	var result outboxResult
+	filter := bson.M{
+		"status":        models.NotificationPending,
+		"nextAttemptAt": bson.M{"$lte": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).SetLimit(limit)
+	cursor, err := config.NotificationCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return result, err
+	}
+	defer cursor.Close(ctx)
+
+	var notifications []models.Notification
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return result, err
+	}
+
+	for i := range notifications {
+		if !deliverNotification(ctx, &notifications[i]) {
+			continue
+		}
+		result.Processed++
+		if notifications[i].Status == models.NotificationSent {
+			result.Sent++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// Fungsi untuk melihat isi outbox notifikasi, bisa difilter dengan ?status=pending|sent|failed
+func GetNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(200)
+	cursor, err := config.NotificationCollection.Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan notifikasi", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		http.Error(w, "Gagal membaca notifikasi", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// Fungsi untuk memproses ulang notifikasi yang tertunda, dari Vercel Cron atau manual oleh staf
+func ProcessNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Second)
+	defer cancel()
+
+	result, err := processOutbox(ctx, 50)
+	if err != nil {
+		http.Error(w, "Gagal memproses notifikasi", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNotificationTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		customer    models.Customer
+		wantChannel string
+		wantTo      string
+	}{
+		{"default WhatsApp", models.Customer{PhoneNumber: "0812", Email: "budi@example.com"}, services.ChannelWhatsApp, "0812"},
+		{"pilih email", models.Customer{PhoneNumber: "0812", Email: "budi@example.com", NotifyVia: services.ChannelEmail}, services.ChannelEmail, "budi@example.com"},
+		{"pilih SMS", models.Customer{PhoneNumber: "0812", NotifyVia: services.ChannelSMS}, services.ChannelSMS, "0812"},
+		{"email kosong kembali ke WhatsApp", models.Customer{PhoneNumber: "0812", NotifyVia: services.ChannelEmail}, services.ChannelWhatsApp, "0812"},
+		{"tanpa telepon memakai email", models.Customer{Email: "budi@example.com"}, services.ChannelEmail, "budi@example.com"},
+		{"tidak mau dihubungi", models.Customer{PhoneNumber: "0812", NotifyVia: "none"}, "", ""},
+		{"tanpa kontak", models.Customer{}, "", ""},
+	}
+
+	for _, tt := range tests {
+		channel, to := notificationTarget(tt.customer)
+		if channel != tt.wantChannel || to != tt.wantTo {
+			t.Errorf("%s: notificationTarget = (%q, %q), want (%q, %q)", tt.name, channel, to, tt.wantChannel, tt.wantTo)
+		}
+	}
+}
+
+// findNotification membaca satu notifikasi dari outbox
+func findNotification(t *testing.T, filter bson.M) models.Notification {
+	t.Helper()
+	var notification models.Notification
+	if err := config.NotificationCollection.FindOne(context.Background(), filter).Decode(&notification); err != nil {
+		t.Fatalf("notifikasi tidak ditemukan: %v", err)
+	}
+	return notification
+}
+
+func TestNotificationOutboxRetry(t *testing.T) {
+	useTestDatabase(t)
+	t.Setenv("NOTIFIER_MODE", "fake")
+	fake := services.SharedFakeNotifier(services.ChannelWhatsApp)
+	fake.Reset()
+	defer fake.Reset()
+	ctx := context.Background()
+
+	customer := models.Customer{ID: primitive.NewObjectID(), FullName: "Budi Santoso", PhoneNumber: "081234567890"}
+	if _, err := config.CustomerCollection.InsertOne(ctx, customer); err != nil {
+		t.Fatal(err)
+	}
+	transaction := models.Transaction{ID: primitive.NewObjectID(), OrderNumber: "LDR-20261019-0002", CustomerID: customer.ID, TotalAmount: 30000}
+
+	// Pengiriman pertama di background gagal, notifikasi tetap pending dan dijadwalkan ulang
+	fake.SetError(errors.New("provider sedang gangguan"))
+	queueNotification(ctx, transaction, services.EventOrderReceived, services.NotificationData{})
+	filter := bson.M{"transactionId": transaction.ID}
+	var notification models.Notification
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		notification = findNotification(t, filter)
+		if notification.Attempts > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if notification.Attempts != 1 || notification.Status != models.NotificationPending || notification.LastError == "" {
+		t.Fatalf("setelah gagal: %+v", notification)
+	}
+	if !notification.NextAttemptAt.After(time.Now().Add(30 * time.Second)) {
+		t.Errorf("percobaan berikutnya %v seharusnya sekitar satu menit lagi", notification.NextAttemptAt)
+	}
+
+	// Cron outbox belum mencoba lagi sebelum jeda selesai
+	fake.SetError(nil)
+	result, err := processOutbox(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Processed != 0 {
+		t.Errorf("outbox memproses %d notifikasi sebelum waktunya", result.Processed)
+	}
+
+	// Setelah jeda selesai, cron outbox mengirim ulang
+	past := bson.M{"$set": bson.M{"nextAttemptAt": time.Now().Add(-time.Second)}}
+	if _, err := config.NotificationCollection.UpdateOne(ctx, filter, past); err != nil {
+		t.Fatal(err)
+	}
+	result, err = processOutbox(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Sent != 1 {
+		t.Fatalf("hasil outbox = %+v, want 1 terkirim", result)
+	}
+	notification = findNotification(t, filter)
+	if notification.Status != models.NotificationSent || notification.Attempts != 2 || notification.SentAt == nil {
+		t.Errorf("setelah terkirim: %+v", notification)
+	}
+	sent := fake.Sent()
+	if len(sent) != 1 || sent[0].To != customer.PhoneNumber || !strings.Contains(sent[0].Body, transaction.OrderNumber) {
+		t.Errorf("pesan terkirim = %+v", sent)
+	}
+
+	// Notifikasi yang sudah terkirim tidak bisa diklaim lagi
+	if deliverNotification(ctx, &notification) {
+		t.Error("notifikasi yang sudah terkirim dikirim ulang")
+	}
+}
+
+func TestNotificationOutboxLeaseAndGiveUp(t *testing.T) {
+	useTestDatabase(t)
+	t.Setenv("NOTIFIER_MODE", "fake")
+	fake := services.SharedFakeNotifier(services.ChannelSMS)
+	fake.Reset()
+	defer fake.Reset()
+	ctx := context.Background()
+
+	notification := models.Notification{
+		ID:            primitive.NewObjectID(),
+		Channel:       services.ChannelSMS,
+		Recipient:     "081234567890",
+		Body:          "Halo",
+		Status:        models.NotificationPending,
+		Attempts:      maxNotificationAttempts - 1,
+		NextAttemptAt: time.Now().Add(-time.Second),
+		CreatedAt:     time.Now(),
+	}
+	if _, err := config.NotificationCollection.InsertOne(ctx, notification); err != nil {
+		t.Fatal(err)
+	}
+
+	// Notifikasi yang sedang dikirim proses lain (jadwalnya sudah dimundurkan) tidak diklaim dua kali
+	leased := bson.M{"$set": bson.M{"nextAttemptAt": time.Now().Add(notificationSendTimeout)}}
+	if _, err := config.NotificationCollection.UpdateOne(ctx, bson.M{"_id": notification.ID}, leased); err != nil {
+		t.Fatal(err)
+	}
+	claim := notification
+	if deliverNotification(ctx, &claim) {
+		t.Fatal("notifikasi yang sedang dikunci ikut dikirim")
+	}
+
+	// Percobaan terakhir yang gagal menandai notifikasi sebagai failed
+	released := bson.M{"$set": bson.M{"nextAttemptAt": time.Now().Add(-time.Second)}}
+	if _, err := config.NotificationCollection.UpdateOne(ctx, bson.M{"_id": notification.ID}, released); err != nil {
+		t.Fatal(err)
+	}
+	fake.SetError(errors.New("nomor tidak aktif"))
+	result, err := processOutbox(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Failed != 1 {
+		t.Fatalf("hasil outbox = %+v, want 1 gagal", result)
+	}
+	stored := findNotification(t, bson.M{"_id": notification.ID})
+	if stored.Status != models.NotificationFailed || stored.Attempts != maxNotificationAttempts {
+		t.Errorf("setelah percobaan terakhir: %+v", stored)
+	}
+}
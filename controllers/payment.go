@@ -4,25 +4,48 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"laundry-pos/config"
 	"laundry-pos/models"
 	"laundry-pos/services"
+	"laundry-pos/utils"
+	"log"
+	"math"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/veritrans/go-midtrans"
+	qrcode "github.com/skip2/go-qrcode"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Membuat pembayaran menggunakan Midtrans
+// Input untuk membuat pembayaran online. Field lain pada pembayaran (refund, kasir, shift, status)
+// selalu diisi oleh server, bukan dari request.
+type createPaymentRequest struct {
+	TransactionID primitive.ObjectID `json:"transactionId"`
+	GrossAmount   float64            `json:"gross_amount"`   // Kosong berarti seluruh sisa tagihan
+	PaymentMethod string             `json:"payment_method"` // snap (bawaan), qris, atau bank_transfer
+	VABank        string             `json:"va_bank"`        // Wajib untuk bank_transfer
+}
+
+// Membuat pembayaran online melalui payment gateway yang dipilih toko
 func CreatePayment(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
-	var paymentReq models.Payment
-	if err := json.NewDecoder(r.Body).Decode(&paymentReq); err != nil {
+	var req createPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Input tidak valid", http.StatusBadRequest)
 		return
 	}
+	paymentReq := models.Payment{
+		TransactionID: req.TransactionID,
+		GrossAmount:   req.GrossAmount,
+		PaymentMethod: req.PaymentMethod,
+		VABank:        req.VABank,
+	}
 
 	// Pastikan transaction_id tersedia
 	if paymentReq.TransactionID.IsZero() {
@@ -30,117 +53,511 @@ func CreatePayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Ambil transaksi dari database berdasarkan TransactionID yang diberikan
-	var transaction models.Transaction
-	err := config.TransactionCollection.FindOne(ctx, bson.M{"_id": paymentReq.TransactionID}).Decode(&transaction)
-	if err != nil {
+	// Ambil transaksi dari database berdasarkan TransactionID yang diberikan, sekaligus hitung sisa tagihan
+	transaction, err := refreshPaymentStatus(ctx, paymentReq.TransactionID)
+	if err == mongo.ErrNoDocuments {
 		http.Error(w, "Transaksi tidak ditemukan", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(w, "Gagal menghitung sisa tagihan", http.StatusInternalServerError)
+		return
+	}
 
-	// Menghitung gross_amount dalam cent
-	// Jangan kalikan dua kali! Pastikan ini adalah dalam rupiah saja (misalnya 16000)
-	// Jika transaksi sudah dalam rupiah, kirim langsung dengan mengalikannya hanya di bagian snapReq.
+	// Ambil informasi customer untuk detail halaman pembayaran dan data konfirmasi
+	var customer models.Customer
+	err = config.CustomerCollection.FindOne(ctx, bson.M{"_id": transaction.CustomerID}).Decode(&customer)
+	if err != nil {
+		http.Error(w, "Customer tidak ditemukan", http.StatusNotFound)
+		return
+	}
 
-	paymentReq.GrossAmount = transaction.TotalAmount // Jangan kalikan dengan 100 di sini, ini sudah dalam rupiah
+	// Permintaan ulang dengan Idempotency-Key yang sama mengembalikan pembayaran yang sudah dibuat
+	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if idempotencyKey != "" {
+		var existing models.Payment
+		err := config.PaymentCollection.FindOne(ctx, bson.M{"idempotency_key": idempotencyKey}).Decode(&existing)
+		if err == nil {
+			if existing.TransactionID != transaction.ID {
+				http.Error(w, "Idempotency-Key sudah dipakai untuk transaksi lain", http.StatusConflict)
+				return
+			}
+			writePaymentResponse(w, existing, transaction, customer)
+			return
+		}
+	}
 
-	// Generate OrderID jika belum disediakan
-	if paymentReq.OrderID == "" {
-		paymentReq.OrderID = uuid.New().String()
+	// payment_method memilih halaman Snap (bawaan), QRIS, atau virtual account yang tampil langsung di layar POS
+	if paymentReq.PaymentMethod == "" {
+		paymentReq.PaymentMethod = services.ChargeRedirect
+	}
+	switch paymentReq.PaymentMethod {
+	case services.ChargeRedirect, services.ChargeQRIS:
+	case services.ChargeVirtualAccount:
+		paymentReq.VABank = strings.ToLower(paymentReq.VABank)
+		if !services.VirtualAccountBanks[paymentReq.VABank] {
+			http.Error(w, "Bank virtual account harus bca, bni, bri, permata, atau cimb", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Metode pembayaran harus snap, qris, atau bank_transfer", http.StatusBadRequest)
+		return
 	}
 
-	// Inisialisasi Midtrans Client dan Snap Gateway
-	midtransClient := services.MidtransClient()
-	snapGateway := midtrans.SnapGateway{Client: *midtransClient}
+	outstanding := outstandingBalance(transaction)
+	if outstanding <= 0 {
+		http.Error(w, "Transaksi sudah lunas", http.StatusBadRequest)
+		return
+	}
 
-	// Membuat request ke Midtrans dengan data transaksi
-	snapReq := &midtrans.SnapReq{
-		TransactionDetails: midtrans.TransactionDetails{
-			OrderID:  paymentReq.OrderID,
-			GrossAmt: int64(paymentReq.GrossAmount), 
-		},
+	// gross_amount boleh diisi untuk uang muka/cicilan, jika kosong berarti bayar seluruh sisa tagihan
+	// Jangan kalikan dengan 100, nilainya sudah dalam rupiah (misalnya 16000)
+	paymentReq.GrossAmount = math.Round(paymentReq.GrossAmount)
+	payRemaining := paymentReq.GrossAmount <= 0
+	if payRemaining {
+		paymentReq.GrossAmount = outstanding
+	}
+	if paymentReq.GrossAmount > outstanding {
+		http.Error(w, fmt.Sprintf("Jumlah pembayaran melebihi sisa tagihan %s", utils.FormatRupiah(outstanding)), http.StatusBadRequest)
+		return
+	}
+
+	// Pakai ulang link Snap yang masih aktif untuk transaksi dan jumlah yang sama, misalnya tombol bayar diklik dua kali
+	gateway, err := services.NewPaymentGateway(loadShopSettings(ctx).PaymentProvider)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !gateway.SupportsMethod(paymentReq.PaymentMethod) {
+		http.Error(w, fmt.Sprintf("Metode pembayaran %s tidak didukung oleh payment gateway %s", paymentReq.PaymentMethod, gateway.Name()), http.StatusBadRequest)
+		return
+	}
+	// Hanya satu request yang boleh membuat tagihan untuk transaksi ini dalam satu waktu. Request lain menunggu
+	// lalu memeriksa ulang tagihan aktif, sehingga klik ganda mendapat link yang sama, bukan tagihan kedua.
+	claimedAt, err := claimPaymentCreation(ctx, transaction.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	defer releasePaymentCreation(transaction.ID, claimedAt)
+
+	now := time.Now()
+	snapConfig := services.LoadSnapConfig()
+	if err := expireStalePayments(ctx, transaction.ID, now, snapConfig.Expiry); err != nil {
+		http.Error(w, "Gagal memeriksa pembayaran sebelumnya", http.StatusInternalServerError)
+		return
+	}
+	var pending models.Payment
+	err = config.PaymentCollection.FindOne(ctx, bson.M{
+		"transactionId":  transaction.ID,
+		"gross_amount":   paymentReq.GrossAmount,
+		"channel":        gateway.Name(),
+		"payment_method": paymentReq.PaymentMethod,
+		"va_bank":        bson.M{"$in": bson.A{paymentReq.VABank, nil}},
+		"status":         bson.M{"$in": pendingPaymentStatuses},
+		"expires_at":     bson.M{"$gt": now},
+	}).Decode(&pending)
+	if err == nil {
+		writePaymentResponse(w, pending, transaction, customer)
+		return
+	}
+
+	// Tagihan online lain yang masih aktif tetap bisa dibayar customer, jadi jumlahnya tidak boleh ditagih dua kali
+	open, err := openChargeAmount(ctx, transaction.ID)
+	if err != nil {
+		http.Error(w, "Gagal memeriksa pembayaran sebelumnya", http.StatusInternalServerError)
+		return
+	}
+	available := outstanding - open
+	if available <= 0 {
+		http.Error(w, fmt.Sprintf("Masih ada pembayaran online aktif sebesar %s, batalkan atau tunggu hingga kedaluwarsa", utils.FormatRupiah(open)), http.StatusConflict)
+		return
+	}
+	if payRemaining {
+		paymentReq.GrossAmount = available
+	}
+	if paymentReq.GrossAmount > available {
+		http.Error(w, fmt.Sprintf("Jumlah pembayaran melebihi sisa tagihan %s (dikurangi pembayaran online aktif %s)", utils.FormatRupiah(available), utils.FormatRupiah(open)), http.StatusBadRequest)
+		return
 	}
 
-	snapResp, err := snapGateway.GetToken(snapReq)
+	// Generate OrderID, diawali nomor order agar mudah dicocokkan di dashboard gateway
+	paymentReq.OrderID = uuid.New().String()
+	if transaction.OrderNumber != "" {
+		paymentReq.OrderID = transaction.OrderNumber + "-" + paymentReq.OrderID[:8]
+	}
+
+	// Buat tagihan di payment gateway yang dipilih toko (Midtrans, Xendit, atau palsu untuk pengujian)
+	chargeResult, err := gateway.CreateCharge(ctx, services.ChargeRequest{
+		Payment:     paymentReq,
+		Transaction: transaction,
+		Customer:    customer,
+		Start:       now,
+		Expiry:      snapConfig.Expiry,
+		Method:      paymentReq.PaymentMethod,
+		Bank:        paymentReq.VABank,
+	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Gagal membuat pembayaran: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Tambahkan snap_url dan status pembayaran
-	paymentReq.SnapURL = snapResp.RedirectURL
+	expiresAt := now.Add(snapConfig.Expiry)
+	paymentReq.SnapURL = chargeResult.RedirectURL
+	paymentReq.QRString = chargeResult.QRString
+	paymentReq.QRImageURL = chargeResult.QRImageURL
+	paymentReq.VANumber = chargeResult.VANumber
+	if chargeResult.Bank != "" {
+		paymentReq.VABank = chargeResult.Bank
+	}
 	paymentReq.Status = "Pending"
-	paymentReq.CreatedAt = time.Now()
+	paymentReq.Channel = gateway.Name()
+	paymentReq.CreatedAt = now
+	paymentReq.ExpiresAt = &expiresAt
+	paymentReq.IdempotencyKey = idempotencyKey
 
-	// Simpan pembayaran
-	_, err = config.PaymentCollection.InsertOne(ctx, paymentReq)
+	// Simpan pembayaran, jika Idempotency-Key yang sama masuk bersamaan pakai pembayaran yang lebih dulu tersimpan
+	result, err := config.PaymentCollection.InsertOne(ctx, paymentReq)
+	if mongo.IsDuplicateKeyError(err) {
+		var existing models.Payment
+		if err := config.PaymentCollection.FindOne(ctx, bson.M{"idempotency_key": idempotencyKey}).Decode(&existing); err == nil {
+			writePaymentResponse(w, existing, transaction, customer)
+			return
+		}
+	}
 	if err != nil {
 		http.Error(w, "Gagal menyimpan pembayaran", http.StatusInternalServerError)
 		return
 	}
+	paymentReq.ID = result.InsertedID.(primitive.ObjectID)
 
-	// Ambil informasi customer dari database berdasarkan CustomerID yang ada di transaksi
-	var customer models.Customer
-	err = config.CustomerCollection.FindOne(ctx, bson.M{"_id": transaction.CustomerID}).Decode(&customer)
+	writePaymentResponse(w, paymentReq, transaction, customer)
+}
+
+// Lama transaksi dikunci saat tagihan dibuat. Kunci yang lebih lama dari ini dianggap tertinggal oleh request
+// yang terhenti, dan request lain menunggu paling lama selama ini.
+const paymentCreationLease = 30 * time.Second
+
+// claimPaymentCreation mengunci transaksi dengan field pendingChargeAt sebelum tagihan dibuat di gateway
+func claimPaymentCreation(ctx context.Context, transactionID primitive.ObjectID) (time.Time, error) {
+	deadline := time.Now().Add(paymentCreationLease)
+	for {
+		// MongoDB menyimpan waktu dalam milidetik, dibulatkan agar releasePaymentCreation bisa mencocokkannya
+		now := time.Now().Truncate(time.Millisecond)
+		filter := bson.M{"_id": transactionID, "$or": bson.A{
+			bson.M{"pendingChargeAt": bson.M{"$exists": false}},
+			bson.M{"pendingChargeAt": bson.M{"$lte": now.Add(-paymentCreationLease)}},
+		}}
+		result, err := config.TransactionCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"pendingChargeAt": now}})
+		if err != nil {
+			return now, fmt.Errorf("Gagal memeriksa pembayaran sebelumnya")
+		}
+		if result.ModifiedCount == 1 {
+			return now, nil
+		}
+		if now.After(deadline) {
+			return now, fmt.Errorf("Pembayaran untuk transaksi ini sedang dibuat, coba lagi sebentar")
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// releasePaymentCreation melepas kunci dari claimPaymentCreation, kunci milik request lain tidak disentuh
+func releasePaymentCreation(transactionID primitive.ObjectID, claimedAt time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	filter := bson.M{"_id": transactionID, "pendingChargeAt": claimedAt}
+	if _, err := config.TransactionCollection.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"pendingChargeAt": ""}}); err != nil {
+		log.Printf("Gagal melepas kunci pembayaran transaksi %s: %v", transactionID.Hex(), err)
+	}
+}
+
+// Status pembayaran yang masih menunggu dibayar (CreatePayment memakai "Pending", Midtrans "pending")
+var pendingPaymentStatuses = []string{"Pending", "pending"}
+
+// expireStalePayments menandai link Snap yang sudah lewat batas waktu sebagai kedaluwarsa
+func expireStalePayments(ctx context.Context, transactionID primitive.ObjectID, now time.Time, expiry time.Duration) error {
+	filter := bson.M{
+		"transactionId": transactionID,
+		"status":        bson.M{"$in": pendingPaymentStatuses},
+		"$or": []bson.M{
+			{"expires_at": bson.M{"$lte": now}},
+			// Pembayaran lama belum memiliki expires_at
+			{"expires_at": bson.M{"$exists": false}, "created_at": bson.M{"$lte": now.Add(-expiry)}},
+		},
+	}
+	_, err := config.PaymentCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"status": "expire"}})
+	return err
+}
+
+// openChargeAmount menjumlahkan tagihan online yang masih pending untuk sebuah transaksi.
+// Panggil expireStalePayments lebih dulu agar link yang sudah kedaluwarsa tidak ikut dihitung.
+func openChargeAmount(ctx context.Context, transactionID primitive.ObjectID) (float64, error) {
+	return sumField(ctx, config.PaymentCollection, bson.M{
+		"transactionId": transactionID,
+		"status":        bson.M{"$in": pendingPaymentStatuses},
+	}, "$gross_amount")
+}
+
+// cancelOpenCharges membatalkan semua tagihan online yang masih pending pada sebuah transaksi di gateway,
+// misalnya sebelum kasir mencatat pembayaran tunai. Tagihan yang gagal dibatalkan (bisa jadi sudah dibayar)
+// menghentikan proses agar transaksi tidak terbayar dua kali.
+func cancelOpenCharges(ctx context.Context, transactionID primitive.ObjectID, now time.Time) error {
+	if err := expireStalePayments(ctx, transactionID, now, services.LoadSnapConfig().Expiry); err != nil {
+		return fmt.Errorf("Gagal memeriksa pembayaran online")
+	}
+	cursor, err := config.PaymentCollection.Find(ctx, bson.M{
+		"transactionId": transactionID,
+		"status":        bson.M{"$in": pendingPaymentStatuses},
+	})
 	if err != nil {
-		http.Error(w, "Customer tidak ditemukan", http.StatusNotFound)
-		return
+		return fmt.Errorf("Gagal memeriksa pembayaran online")
+	}
+	var open []models.Payment
+	if err := cursor.All(ctx, &open); err != nil {
+		return fmt.Errorf("Gagal memeriksa pembayaran online")
 	}
 
+	for _, payment := range open {
+		gateway, err := paymentGateway(payment)
+		if err != nil {
+			return err
+		}
+		if err := gateway.Cancel(ctx, payment.OrderID); err != nil {
+			return fmt.Errorf("Pembayaran online %s masih aktif dan gagal dibatalkan: %v", payment.OrderID, err)
+		}
+		if _, err := applyGatewayStatus(ctx, payment.OrderID, services.GatewayCancel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePaymentResponse mengirim snap_url, order_id, dan data konfirmasi untuk sebuah pembayaran
+func writePaymentResponse(w http.ResponseWriter, payment models.Payment, transaction models.Transaction, customer models.Customer) {
 	// Kirim data konfirmasi pembayaran yang diperlukan
 	confirmationData := map[string]interface{}{
 		"fullName":     customer.FullName,
 		"phoneNumber":  customer.PhoneNumber,
 		"email":        customer.Email,
-		"service_name": transaction.Items[0].Service.ServiceName,
-		"quantity":     transaction.Items[0].Quantity,
 		"total_amount": transaction.TotalAmount,
+		"amount_paid":  transaction.AmountPaid,
+		"outstanding":  outstandingBalance(transaction),
+	}
+	if len(transaction.Items) > 0 {
+		confirmationData["service_name"] = transaction.Items[0].Service.ServiceName
+		confirmationData["quantity"] = transaction.Items[0].Quantity
 	}
 
 	// Kirim response dengan snap_url, order_id, dan konfirmasi data
 	response := map[string]interface{}{
-		"snap_url":          paymentReq.SnapURL,
-		"order_id":          paymentReq.OrderID,
+		"payment_id":        payment.ID.Hex(),
+		"snap_url":          payment.SnapURL,
+		"order_id":          payment.OrderID,
+		"gross_amount":      payment.GrossAmount,
+		"status":            payment.Status,
+		"expires_at":        payment.ExpiresAt,
+		"payment_method":    payment.PaymentMethod,
+		"qr_string":         payment.QRString,
+		"qr_image_url":      payment.QRImageURL,
+		"va_bank":           payment.VABank,
+		"va_number":         payment.VANumber,
+		"callbacks":         snapCallbacks(),
 		"confirmation_data": confirmationData, // Data konfirmasi transaksi
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// snapCallbacks mengembalikan URL redirect untuk callback Snap.js (onSuccess, onPending/onClose, onError)
+func snapCallbacks() map[string]string {
+	cfg := services.LoadSnapConfig()
+	return map[string]string{
+		"finish":   cfg.FinishURL,
+		"unfinish": cfg.UnfinishURL,
+		"error":    cfg.ErrorURL,
+	}
+}
+
 // WebhookHandler menangani notifikasi dari Midtrans
 func WebhookHandler(w http.ResponseWriter, r *http.Request) {
-    var notificationPayload map[string]interface{}
-    if err := json.NewDecoder(r.Body).Decode(&notificationPayload); err != nil {
+    handleGatewayWebhook(w, r, services.ProviderMidtrans)
+}
+
+// XenditWebhookHandler menangani callback invoice dari Xendit
+func XenditWebhookHandler(w http.ResponseWriter, r *http.Request) {
+    handleGatewayWebhook(w, r, services.ProviderXendit)
+}
+
+// handleGatewayWebhook menyimpan notifikasi mentah dari gateway, memverifikasinya, lalu memperbarui status pembayaran.
+// Notifikasi ulang yang sudah pernah diproses langsung dibalas OK tanpa diproses lagi.
+func handleGatewayWebhook(w http.ResponseWriter, r *http.Request, provider string) {
+    gateway, err := services.NewPaymentGateway(provider)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+    if err != nil {
         http.Error(w, "Invalid payload", http.StatusBadRequest)
         return
     }
 
-    // Ambil Order ID dari payload
-    orderID, ok := notificationPayload["order_id"].(string)
-    if !ok {
-        http.Error(w, "Order ID tidak valid", http.StatusBadRequest)
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+    defer cancel()
+
+    event := newWebhookEvent(provider, body, time.Now())
+    notification, verifyErr := gateway.VerifyNotification(r.Header, body)
+    if verifyErr != nil {
+        event.Result = models.WebhookRejected
+        event.Error = verifyErr.Error()
+    } else {
+        event.OrderID = notification.OrderID
+        event.Status = notification.Status
+        event.GrossAmount = notification.GrossAmount
+        event.PaymentType = notification.PaymentType
+    }
+
+    event, duplicate, err := storeWebhookEvent(ctx, event)
+    if err != nil {
+        http.Error(w, "Gagal menyimpan notifikasi", http.StatusInternalServerError)
+        return
+    }
+    if verifyErr != nil {
+        http.Error(w, "Notifikasi tidak valid: "+verifyErr.Error(), http.StatusUnauthorized)
+        return
+    }
+    if duplicate && event.Result != models.WebhookFailed {
+        w.WriteHeader(http.StatusOK)
         return
     }
 
-    // Ambil status pembayaran dari payload
-    transactionStatus, ok := notificationPayload["transaction_status"].(string)
-    if !ok {
-        http.Error(w, "Status transaksi tidak valid", http.StatusBadRequest)
+    if _, err := processWebhookEvent(ctx, event); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
 
-    ctx := context.Background()
+    // Response ke gateway bahwa notifikasi diterima
+    w.WriteHeader(http.StatusOK)
+}
 
-    // Update status pembayaran di database
+// Urutan status pembayaran gateway. Status hanya boleh berpindah ke urutan yang sama atau lebih tinggi
+// sehingga notifikasi "pending" yang datang terlambat tidak menimpa "settlement".
+// Settlement tetap diterima setelah expire/cancel karena customer bisa saja membayar tepat sebelum link ditutup.
+var gatewayStatusRank = map[string]int{
+    "Pending":                     0,
+    services.GatewayPending:       0,
+    "authorize":                   0,
+    services.GatewayDeny:          1,
+    services.GatewayCancel:        1,
+    services.GatewayExpire:        1,
+    "failure":                     1,
+    services.GatewaySettlement:    2,
+    "capture":                     2,
+    services.GatewayPartialRefund: 3,
+    services.GatewayRefund:        4,
+}
+
+// statusesReplaceableBy mengembalikan status tersimpan yang boleh ditimpa oleh status baru
+func statusesReplaceableBy(status string) []string {
+    rank := gatewayStatusRank[status]
+    statuses := []string{}
+    for candidate, candidateRank := range gatewayStatusRank {
+        if candidateRank <= rank {
+            statuses = append(statuses, candidate)
+        }
+    }
+    return statuses
+}
+
+// applyGatewayStatus menyimpan status pembayaran dari gateway, menghitung ulang pelunasan,
+// dan mengirim notifikasi jika pembayaran baru diterima. Hasilnya salah satu konstanta models.Webhook*:
+// order yang tidak dikenal dan status yang lebih lama dari status tersimpan diabaikan.
+func applyGatewayStatus(ctx context.Context, orderID, transactionStatus string) (string, error) {
+    // Update status pembayaran di database, ambil data sebelum update untuk mendeteksi pelunasan baru
+    var previous models.Payment
+    filter := bson.M{"order_id": orderID, "status": bson.M{"$in": statusesReplaceableBy(transactionStatus)}}
     update := bson.M{"$set": bson.M{"status": transactionStatus}}
-    _, err := config.PaymentCollection.UpdateOne(ctx, bson.M{"order_id": orderID}, update)
+    err := config.PaymentCollection.FindOneAndUpdate(ctx, filter, update).Decode(&previous)
+    if err == mongo.ErrNoDocuments {
+        count, err := config.PaymentCollection.CountDocuments(ctx, bson.M{"order_id": orderID})
+        if err != nil {
+            return models.WebhookFailed, fmt.Errorf("Gagal memeriksa pembayaran")
+        }
+        if count == 0 {
+            return models.WebhookUnknownOrder, nil
+        }
+        return models.WebhookIgnored, nil
+    }
     if err != nil {
-        http.Error(w, "Gagal memperbarui status pembayaran", http.StatusInternalServerError)
-        return
+        return models.WebhookFailed, fmt.Errorf("Gagal memperbarui status pembayaran")
     }
 
-    // Response ke Midtrans bahwa notifikasi diterima
-    w.WriteHeader(http.StatusOK)
+    // Refund penuh dari dashboard gateway tidak melalui endpoint refund, anggap seluruh dana sudah kembali
+    if transactionStatus == services.GatewayRefund && previous.RefundedAmount < previous.GrossAmount {
+        refunded := bson.M{"$set": bson.M{"refunded_amount": previous.GrossAmount}}
+        if _, err := config.PaymentCollection.UpdateOne(ctx, bson.M{"_id": previous.ID}, refunded); err != nil {
+            return models.WebhookFailed, fmt.Errorf("Gagal memperbarui status pembayaran")
+        }
+    }
+
+    // Hitung ulang pelunasan transaksi jika status berubah (settle baru, dibatalkan, atau refund)
+    if transactionStatus == previous.Status {
+        return models.WebhookIgnored, nil
+    }
+    newlySettled := isSettledStatus(transactionStatus) && !isCollectedStatus(previous.Status)
+    if newlySettled {
+        // Laporan mengelompokkan pembayaran berdasarkan waktu dana diterima, bukan waktu tagihan dibuat
+        settled := bson.M{"$set": bson.M{"settled_at": time.Now()}}
+        if _, err := config.PaymentCollection.UpdateOne(ctx, bson.M{"_id": previous.ID}, settled); err != nil {
+            return models.WebhookFailed, fmt.Errorf("Gagal memperbarui status pembayaran")
+        }
+    }
+    transaction, err := refreshPaymentStatus(ctx, previous.TransactionID)
+    if err != nil {
+        return models.WebhookFailed, fmt.Errorf("Gagal memperbarui status pelunasan")
+    }
+
+    // Kirim notifikasi pembayaran diterima hanya sekali saat status berubah menjadi settle
+    if newlySettled {
+        queueNotification(ctx, transaction, services.EventPaymentReceived, services.NotificationData{
+            Amount: utils.FormatRupiah(previous.GrossAmount),
+        })
+    }
+    return models.WebhookApplied, nil
+}
+
+// GetPaymentQR merender QRIS pembayaran sebagai PNG untuk ditampilkan di layar POS
+func GetPaymentQR(w http.ResponseWriter, r *http.Request) {
+	orderID := r.URL.Query().Get("order_id")
+	if orderID == "" {
+		http.Error(w, "Order ID tidak disediakan", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var payment models.Payment
+	if err := config.PaymentCollection.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&payment); err != nil {
+		http.Error(w, "Pembayaran tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	if payment.QRString == "" {
+		http.Error(w, "Pembayaran ini bukan QRIS", http.StatusBadRequest)
+		return
+	}
+
+	png, err := qrcode.Encode(payment.QRString, qrcode.Medium, 320)
+	if err != nil {
+		http.Error(w, "Gagal membuat gambar QR", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(png)
 }
 
 // GetPaymentByOrderID mendapatkan data pembayaran berdasarkan OrderID
@@ -194,3 +611,131 @@ func GetAllPayments(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(payments)
 }
+
+// Status Midtrans yang menandakan pembayaran sudah diterima
+var settledPaymentStatuses = []string{"settlement", "capture"}
+
+// Status pembayaran yang dananya pernah diterima, termasuk yang sudah dikembalikan sebagian/seluruhnya.
+// Nilai bersihnya adalah gross_amount dikurangi refunded_amount.
+var collectedPaymentStatuses = []string{"settlement", "capture", "partial_refund", "refund"}
+
+// isSettledStatus memeriksa apakah status Midtrans menandakan pembayaran diterima
+func isSettledStatus(status string) bool {
+	for _, settled := range settledPaymentStatuses {
+		if status == settled {
+			return true
+		}
+	}
+	return false
+}
+
+// isCollectedStatus memeriksa apakah dana pembayaran pernah diterima (termasuk yang sudah direfund)
+func isCollectedStatus(status string) bool {
+	for _, collected := range collectedPaymentStatuses {
+		if status == collected {
+			return true
+		}
+	}
+	return false
+}
+
+// netPaymentAmount adalah ekspresi agregasi untuk nilai pembayaran setelah dikurangi refund
+var netPaymentAmount = bson.M{"$subtract": bson.A{"$gross_amount", bson.M{"$ifNull": bson.A{"$refunded_amount", 0}}}}
+
+// paidAtExpression adalah ekspresi agregasi untuk waktu dana diterima. Pembayaran lama belum memiliki settled_at.
+var paidAtExpression = bson.M{"$ifNull": bson.A{"$settled_at", "$created_at"}}
+
+// paidBetween membuat filter pembayaran yang dananya diterima dalam rentang waktu [from, end)
+func paidBetween(from, end time.Time) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"settled_at": bson.M{"$gte": from, "$lt": end}},
+		bson.M{"settled_at": bson.M{"$exists": false}, "created_at": bson.M{"$gte": from, "$lt": end}},
+	}}
+}
+
+// settledAmount menjumlahkan nilai bersih pembayaran yang sudah diterima untuk sebuah transaksi
+func settledAmount(ctx context.Context, transactionID primitive.ObjectID) (float64, error) {
+	return sumField(ctx, config.PaymentCollection, bson.M{
+		"transactionId": transactionID,
+		"status":        bson.M{"$in": collectedPaymentStatuses},
+	}, netPaymentAmount)
+}
+
+// paymentStatusFor menentukan status pelunasan dari total tagihan dan jumlah yang sudah dibayar
+func paymentStatusFor(total, paid float64) string {
+	switch {
+	case paid <= 0 && total > 0:
+		return models.PaymentUnpaid
+	case paid < total:
+		return models.PaymentPartial
+	default:
+		return models.PaymentPaid
+	}
+}
+
+// refreshPaymentStatus menghitung ulang jumlah terbayar dan status pelunasan transaksi
+func refreshPaymentStatus(ctx context.Context, transactionID primitive.ObjectID) (models.Transaction, error) {
+	var transaction models.Transaction
+	if err := config.TransactionCollection.FindOne(ctx, bson.M{"_id": transactionID}).Decode(&transaction); err != nil {
+		return transaction, err
+	}
+
+	paid, err := settledAmount(ctx, transactionID)
+	if err != nil {
+		return transaction, err
+	}
+
+	transaction.AmountPaid = paid
+	transaction.PaymentStatus = paymentStatusFor(transaction.TotalAmount, paid)
+	update := bson.M{"$set": bson.M{"amountPaid": transaction.AmountPaid, "paymentStatus": transaction.PaymentStatus}}
+	_, err = config.TransactionCollection.UpdateOne(ctx, bson.M{"_id": transactionID}, update)
+	return transaction, err
+}
+
+// outstandingBalance mengembalikan sisa tagihan transaksi, tidak pernah negatif
+func outstandingBalance(transaction models.Transaction) float64 {
+	return math.Max(transaction.TotalAmount-transaction.AmountPaid, 0)
+}
+
+// Fungsi untuk melihat semua pembayaran dari satu transaksi beserta sisa tagihannya
+func GetTransactionPayments(w http.ResponseWriter, r *http.Request) {
+	objID, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// amountPaid dan paymentStatus sudah diperbarui setiap kali pembayaran berubah, cukup dibaca
+	var transaction models.Transaction
+	if err := config.TransactionCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&transaction); err != nil {
+		http.Error(w, "Transaksi tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := config.PaymentCollection.Find(ctx, bson.M{"transactionId": objID}, opts)
+	if err != nil {
+		http.Error(w, "Gagal mengambil data pembayaran", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	payments := []models.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		http.Error(w, "Gagal memproses data pembayaran", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"transactionId": objID.Hex(),
+		"totalAmount":   transaction.TotalAmount,
+		"amountPaid":    transaction.AmountPaid,
+		"outstanding":   outstandingBalance(transaction),
+		"paymentStatus": transaction.PaymentStatus,
+		"payments":      payments,
+	})
+}
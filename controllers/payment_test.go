@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestGatewayStatusRankOrder(t *testing.T) {
+	// Setiap status harus berada di urutan yang lebih tinggi dari status sebelumnya
+	order := [][]string{
+		{"Pending", services.GatewayPending, "authorize"},
+		{services.GatewayDeny, services.GatewayCancel, services.GatewayExpire, "failure"},
+		{services.GatewaySettlement, "capture"},
+		{services.GatewayPartialRefund},
+		{services.GatewayRefund},
+	}
+	for level, statuses := range order {
+		for _, status := range statuses {
+			rank, ok := gatewayStatusRank[status]
+			if !ok {
+				t.Errorf("status %q tidak memiliki urutan", status)
+				continue
+			}
+			for _, lower := range order[:level] {
+				if gatewayStatusRank[lower[0]] >= rank {
+					t.Errorf("urutan %q (%d) seharusnya di atas %q (%d)", status, rank, lower[0], gatewayStatusRank[lower[0]])
+				}
+			}
+		}
+	}
+}
+
+func TestStatusesReplaceableBy(t *testing.T) {
+	tests := []struct {
+		status    string
+		replaces  []string
+		preserves []string
+	}{
+		{
+			status:    services.GatewayPending,
+			replaces:  []string{"Pending", services.GatewayPending},
+			preserves: []string{services.GatewayExpire, services.GatewaySettlement, services.GatewayRefund},
+		},
+		{
+			status:    services.GatewayExpire,
+			replaces:  []string{"Pending", services.GatewayPending, services.GatewayCancel},
+			preserves: []string{services.GatewaySettlement, "capture", services.GatewayPartialRefund},
+		},
+		{
+			// Customer bisa membayar tepat sebelum link ditutup
+			status:    services.GatewaySettlement,
+			replaces:  []string{"Pending", services.GatewayExpire, services.GatewayCancel, services.GatewaySettlement},
+			preserves: []string{services.GatewayPartialRefund, services.GatewayRefund},
+		},
+		{
+			status:    services.GatewayRefund,
+			replaces:  []string{services.GatewaySettlement, services.GatewayPartialRefund, services.GatewayRefund},
+			preserves: []string{},
+		},
+		{
+			// Status yang tidak dikenal diperlakukan seperti pending
+			status:    "unknown",
+			replaces:  []string{"Pending", services.GatewayPending},
+			preserves: []string{services.GatewayExpire, services.GatewaySettlement},
+		},
+	}
+
+	for _, tt := range tests {
+		replaceable := map[string]bool{}
+		for _, status := range statusesReplaceableBy(tt.status) {
+			replaceable[status] = true
+		}
+		for _, status := range tt.replaces {
+			if !replaceable[status] {
+				t.Errorf("%q seharusnya boleh menimpa %q", tt.status, status)
+			}
+		}
+		for _, status := range tt.preserves {
+			if replaceable[status] {
+				t.Errorf("%q seharusnya tidak boleh menimpa %q", tt.status, status)
+			}
+		}
+	}
+}
+
+func TestPaymentStatusFor(t *testing.T) {
+	tests := []struct {
+		total float64
+		paid  float64
+		want  string
+	}{
+		{45000, 0, models.PaymentUnpaid},
+		{45000, 20000, models.PaymentPartial},
+		{45000, 45000, models.PaymentPaid},
+		{45000, 50000, models.PaymentPaid},
+		{0, 0, models.PaymentPaid},
+	}
+
+	for _, tt := range tests {
+		if got := paymentStatusFor(tt.total, tt.paid); got != tt.want {
+			t.Errorf("paymentStatusFor(%v, %v) = %q, want %q", tt.total, tt.paid, got, tt.want)
+		}
+	}
+}
+
+// useTestDatabase mengarahkan koleksi ke database sementara di MONGODB_TEST_URI, test dilewati jika tidak diatur
+func useTestDatabase(t *testing.T) {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI tidak diatur")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("gagal terhubung ke MongoDB: %v", err)
+	}
+	db := client.Database(fmt.Sprintf("laundry-pos-test-%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	config.Client = client
+	config.UserCollection = db.Collection("user")
+	config.CustomerCollection = db.Collection("customers")
+	config.InventoryCollection = db.Collection("inventory")
+	config.PaymentCollection = db.Collection("payments")
+	config.ServiceCollection = db.Collection("service")
+	config.TransactionCollection = db.Collection("transactions")
+	config.SettingsCollection = db.Collection("settings")
+	config.CounterCollection = db.Collection("counters")
+	config.NotificationCollection = db.Collection("notifications")
+	config.ReminderCollection = db.Collection("reminders")
+	config.RefundCollection = db.Collection("refunds")
+	config.ShiftCollection = db.Collection("shifts")
+	config.CashMovementCollection = db.Collection("cash_movements")
+	config.WebhookEventCollection = db.Collection("webhook_events")
+	config.StockMovementCollection = db.Collection("stock_movements")
+	config.TrackingAttemptCollection = db.Collection("tracking_attempts")
+}
+
+// createTestPayment memanggil CreatePayment dan mengembalikan order_id pembayaran yang dibuat
+func createTestPayment(t *testing.T, transactionID primitive.ObjectID, amount float64) string {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"transactionId": transactionID, "gross_amount": amount})
+	rec := httptest.NewRecorder()
+	CreatePayment(rec, httptest.NewRequest(http.MethodPost, "/payments", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("CreatePayment status %d: %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		OrderID string `json:"order_id"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || response.OrderID == "" {
+		t.Fatalf("response CreatePayment tidak valid: %v", err)
+	}
+	return response.OrderID
+}
+
+// sendTestWebhook mengirim notifikasi bergaya Midtrans ke FakeGateway
+func sendTestWebhook(t *testing.T, orderID, status string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"order_id": orderID, "transaction_status": status})
+	rec := httptest.NewRecorder()
+	WebhookHandler(rec, httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("webhook %s status %d: %s", status, rec.Code, rec.Body.String())
+	}
+}
+
+func TestCreatePaymentWebhookFlow(t *testing.T) {
+	useTestDatabase(t)
+	t.Setenv("PAYMENT_GATEWAY_MODE", "fake")
+	ctx := context.Background()
+
+	customer := models.Customer{ID: primitive.NewObjectID(), FullName: "Budi Santoso", PhoneNumber: "081234567890"}
+	if _, err := config.CustomerCollection.InsertOne(ctx, customer); err != nil {
+		t.Fatal(err)
+	}
+	transaction := models.Transaction{
+		ID:              primitive.NewObjectID(),
+		OrderNumber:     "LDR-20261019-0001",
+		CustomerID:      customer.ID,
+		TransactionDate: time.Now(),
+		Items: []models.TransactionItem{{
+			Service:    models.Service{ServiceName: "Cuci Kering", Unit: "kg"},
+			Quantity:   5,
+			UnitPrice:  9000,
+			TotalPrice: 45000,
+		}},
+		TotalAmount:   45000,
+		PaymentStatus: models.PaymentUnpaid,
+		Status:        models.TransactionPending,
+	}
+	if _, err := config.TransactionCollection.InsertOne(ctx, transaction); err != nil {
+		t.Fatal(err)
+	}
+
+	// Uang muka dibayar lewat gateway
+	deposit := createTestPayment(t, transaction.ID, 20000)
+	sendTestWebhook(t, deposit, services.GatewaySettlement)
+	refreshed, err := refreshPaymentStatus(ctx, transaction.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.AmountPaid != 20000 || refreshed.PaymentStatus != models.PaymentPartial {
+		t.Fatalf("setelah uang muka: dibayar %v status %q, want 20000 %q", refreshed.AmountPaid, refreshed.PaymentStatus, models.PaymentPartial)
+	}
+
+	// Notifikasi pending yang datang terlambat tidak boleh membatalkan settlement
+	sendTestWebhook(t, deposit, services.GatewayPending)
+
+	// Pelunasan tanpa gross_amount menagih seluruh sisa tagihan
+	rest := createTestPayment(t, transaction.ID, 0)
+	sendTestWebhook(t, rest, services.GatewaySettlement)
+	refreshed, err = refreshPaymentStatus(ctx, transaction.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.AmountPaid != 45000 || refreshed.PaymentStatus != models.PaymentPaid {
+		t.Fatalf("setelah pelunasan: dibayar %v status %q, want 45000 %q", refreshed.AmountPaid, refreshed.PaymentStatus, models.PaymentPaid)
+	}
+}
+
+func TestCreatePaymentConcurrentRequestsShareCharge(t *testing.T) {
+	useTestDatabase(t)
+	t.Setenv("PAYMENT_GATEWAY_MODE", "fake")
+	ctx := context.Background()
+
+	customer := models.Customer{ID: primitive.NewObjectID(), FullName: "Siti Aminah", PhoneNumber: "081298765432"}
+	if _, err := config.CustomerCollection.InsertOne(ctx, customer); err != nil {
+		t.Fatal(err)
+	}
+	transaction := models.Transaction{
+		ID:            primitive.NewObjectID(),
+		OrderNumber:   "LDR-20261019-0003",
+		CustomerID:    customer.ID,
+		TotalAmount:   30000,
+		PaymentStatus: models.PaymentUnpaid,
+	}
+	if _, err := config.TransactionCollection.InsertOne(ctx, transaction); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tombol bayar diklik dua kali tanpa Idempotency-Key: keduanya harus mendapat tagihan yang sama
+	orderIDs := make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			body, _ := json.Marshal(map[string]interface{}{"transactionId": transaction.ID})
+			rec := httptest.NewRecorder()
+			CreatePayment(rec, httptest.NewRequest(http.MethodPost, "/create-payment", bytes.NewReader(body)))
+			var response struct {
+				OrderID string `json:"order_id"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			orderIDs <- response.OrderID
+		}()
+	}
+	first, second := <-orderIDs, <-orderIDs
+	if first == "" || first != second {
+		t.Errorf("order_id = %q dan %q, want tagihan yang sama", first, second)
+	}
+	count, err := config.PaymentCollection.CountDocuments(ctx, bson.M{"transactionId": transaction.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("jumlah tagihan = %d, want 1", count)
+	}
+}
@@ -0,0 +1,373 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	qrcode "github.com/skip2/go-qrcode"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Satu baris pada struk, dipakai bersama oleh format ESC/POS, HTML, dan PDF
+type receiptLine struct {
+	Left      string
+	Right     string
+	Center    bool
+	Bold      bool
+	Large     bool
+	Separator bool
+}
+
+// Isi struk/tiket yang sudah disusun sebelum dirender
+type receiptDoc struct {
+	Title  string
+	Lines  []receiptLine
+	QRData string
+}
+
+// Fungsi untuk mencetak struk customer atau tiket produksi dari sebuah transaksi
+// Query: id, type=receipt|ticket, format=escpos|html|pdf, width=58|80
+func GetTransactionReceipt(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	objID, err := primitive.ObjectIDFromHex(query.Get("id"))
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	paperMM := 58
+	if width := query.Get("width"); width != "" {
+		paperMM, err = strconv.Atoi(width)
+		if err != nil || (paperMM != 58 && paperMM != 80) {
+			http.Error(w, "Lebar kertas harus 58 atau 80", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ticket := query.Get("type") == "ticket"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var transaction models.Transaction
+	err = config.TransactionCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&transaction)
+	if err != nil {
+		http.Error(w, "Transaksi tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	var customer models.Customer
+	err = config.CustomerCollection.FindOne(ctx, bson.M{"_id": transaction.CustomerID}).Decode(&customer)
+	if err == nil {
+		transaction.Customer = customer
+	}
+
+	settings := loadShopSettings(ctx)
+	doc := buildReceiptDoc(transaction, settings, ticket)
+
+	switch query.Get("format") {
+	case "", "escpos":
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.bin", doc.QRData))
+		w.Write(renderEscPos(doc, paperMM))
+	case "html":
+		body, err := renderReceiptHTML(doc, paperMM)
+		if err != nil {
+			http.Error(w, "Gagal membuat struk", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(body)
+	case "pdf":
+		body, err := renderReceiptPDF(doc, paperMM)
+		if err != nil {
+			http.Error(w, "Gagal membuat struk", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", doc.QRData))
+		w.Write(body)
+	default:
+		http.Error(w, "Format harus escpos, html, atau pdf", http.StatusBadRequest)
+	}
+}
+
+// buildReceiptDoc menyusun isi struk customer (dengan harga) atau tiket produksi (tanpa harga)
+func buildReceiptDoc(transaction models.Transaction, settings models.ShopSettings, ticket bool) receiptDoc {
+	loc := utils.ShopLocation(settings)
+	orderRef := transaction.OrderNumber
+	if orderRef == "" {
+		orderRef = transaction.ID.Hex()
+	}
+	doc := receiptDoc{Title: settings.ShopName, QRData: orderRef}
+
+	add := func(line receiptLine) { doc.Lines = append(doc.Lines, line) }
+	separator := receiptLine{Separator: true}
+
+	add(receiptLine{Left: settings.ShopName, Center: true, Bold: true, Large: true})
+	if settings.ShopAddress != "" {
+		add(receiptLine{Left: settings.ShopAddress, Center: true})
+	}
+	if settings.ShopPhone != "" {
+		add(receiptLine{Left: "Telp. " + settings.ShopPhone, Center: true})
+	}
+	if ticket {
+		add(receiptLine{Left: "TIKET PRODUKSI", Center: true, Bold: true})
+	}
+	add(separator)
+
+	add(receiptLine{Left: "No. Order", Right: orderRef})
+	add(receiptLine{Left: "Tanggal", Right: transaction.TransactionDate.In(loc).Format("02/01/2006 15:04")})
+	add(receiptLine{Left: "Customer", Right: transaction.Customer.FullName})
+	if !ticket && transaction.Customer.PhoneNumber != "" {
+		add(receiptLine{Left: "Telepon", Right: transaction.Customer.PhoneNumber})
+	}
+	if !transaction.EstimatedCompletion.IsZero() {
+		add(receiptLine{Left: "Selesai", Right: transaction.EstimatedCompletion.In(loc).Format("02/01/2006 15:04"), Bold: ticket})
+	}
+	add(separator)
+
+	for _, item := range transaction.Items {
+		name := item.Service.ServiceName
+		if item.SpeedTier != "" && item.SpeedTier != models.SpeedRegular {
+			name += " (" + item.SpeedTier + ")"
+		}
+		add(receiptLine{Left: name, Bold: true})
+
+		quantity := fmt.Sprintf("%d %s", item.Quantity, item.Service.Unit)
+		if ticket {
+			add(receiptLine{Left: "  Jumlah", Right: quantity})
+		} else {
+			// TotalPrice sudah termasuk biaya kilat/express, rinciannya ditampilkan sebelum total item
+			if item.Surcharge > 0 {
+				base := float64(item.Quantity) * item.UnitPrice
+				add(receiptLine{Left: "  " + quantity + " x " + utils.FormatRupiah(item.UnitPrice), Right: utils.FormatRupiah(base)})
+				add(receiptLine{Left: "  + Biaya kilat/express", Right: utils.FormatRupiah(item.Surcharge)})
+				add(receiptLine{Left: "  Total item", Right: utils.FormatRupiah(item.TotalPrice)})
+			} else {
+				add(receiptLine{Left: "  " + quantity + " x " + utils.FormatRupiah(item.UnitPrice), Right: utils.FormatRupiah(item.TotalPrice)})
+			}
+		}
+
+		for _, piece := range item.Pieces {
+			add(receiptLine{Left: "  [" + piece.TagCode + "] " + piece.Description})
+			if ticket && piece.DamageNotes != "" {
+				add(receiptLine{Left: "    Catatan: " + piece.DamageNotes})
+			}
+		}
+	}
+	add(separator)
+
+	if !ticket {
+		if transaction.DeliveryFee > 0 || transaction.StorageFee > 0 {
+			add(receiptLine{Left: "Subtotal", Right: utils.FormatRupiah(transaction.Subtotal)})
+		}
+		if transaction.DeliveryFee > 0 {
+			add(receiptLine{Left: "Ongkos antar-jemput", Right: utils.FormatRupiah(transaction.DeliveryFee)})
+		}
+		if transaction.StorageFee > 0 {
+			add(receiptLine{Left: "Biaya penyimpanan", Right: utils.FormatRupiah(transaction.StorageFee)})
+		}
+		add(receiptLine{Left: "TOTAL", Right: utils.FormatRupiah(transaction.TotalAmount), Bold: true})
+
+		if transaction.PaymentStatus == models.PaymentPartial {
+			add(receiptLine{Left: "Dibayar", Right: utils.FormatRupiah(transaction.AmountPaid)})
+			add(receiptLine{Left: "Sisa", Right: utils.FormatRupiah(outstandingBalance(transaction)), Bold: true})
+		}
+
+		status := "BELUM LUNAS"
+		switch transaction.PaymentStatus {
+		case models.PaymentPaid:
+			status = "LUNAS"
+		case models.PaymentPartial:
+			status = "DP / SEBAGIAN"
+		}
+		add(receiptLine{Left: "Status", Right: status, Bold: true})
+		add(separator)
+	}
+
+	if transaction.Delivery != nil {
+		add(receiptLine{Left: "Diantar ke: " + transaction.Delivery.Address})
+	}
+	if !ticket && transaction.TrackingCode != "" {
+		add(receiptLine{Left: "Kode lacak: " + transaction.TrackingCode, Center: true})
+	}
+	if !ticket && settings.ReceiptFooter != "" {
+		add(receiptLine{Left: settings.ReceiptFooter, Center: true})
+	}
+
+	return doc
+}
+
+// renderEscPos mengubah isi struk menjadi perintah printer thermal
+func renderEscPos(doc receiptDoc, paperMM int) []byte {
+	printer := utils.NewEscPos(paperMM)
+	for _, line := range doc.Lines {
+		if line.Separator {
+			printer.Separator()
+			continue
+		}
+
+		align := utils.AlignLeft
+		if line.Center {
+			align = utils.AlignCenter
+		}
+		printer.Align(align)
+		printer.Bold(line.Bold)
+		printer.DoubleSize(line.Large)
+
+		if line.Right != "" {
+			printer.Columns(line.Left, line.Right)
+		} else {
+			printer.Line(line.Left)
+		}
+
+		printer.DoubleSize(false)
+		printer.Bold(false)
+	}
+
+	printer.Align(utils.AlignCenter)
+	printer.QRCode(doc.QRData, 6)
+	printer.Line(doc.QRData)
+	printer.Align(utils.AlignLeft)
+	printer.Feed(3)
+	printer.Cut()
+	return printer.Bytes()
+}
+
+var receiptTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  @page { size: {{.PaperMM}}mm auto; margin: 2mm; }
+  body { width: {{.PaperMM}}mm; font-family: monospace; font-size: 11px; margin: 0 auto; }
+  .row { display: flex; justify-content: space-between; gap: 4px; }
+  .center { text-align: center; justify-content: center; }
+  .bold { font-weight: bold; }
+  .large { font-size: 16px; }
+  hr { border: none; border-top: 1px dashed #000; }
+  img { display: block; margin: 4px auto; width: 60%; }
+</style>
+</head>
+<body onload="window.print()">
+{{range .Lines}}{{if .Separator}}<hr>{{else}}<div class="row{{if .Center}} center{{end}}{{if .Bold}} bold{{end}}{{if .Large}} large{{end}}"><span>{{.Left}}</span>{{if .Right}}<span>{{.Right}}</span>{{end}}</div>
+{{end}}{{end}}
+{{if .QRImage}}<img src="{{.QRImage}}" alt="{{.QRData}}">{{end}}
+<div class="row center">{{.QRData}}</div>
+</body>
+</html>
+`))
+
+// renderReceiptHTML membuat struk HTML yang langsung memanggil dialog cetak di browser
+func renderReceiptHTML(doc receiptDoc, paperMM int) ([]byte, error) {
+	png, err := qrcode.Encode(doc.QRData, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		receiptDoc
+		PaperMM int
+		QRImage template.URL
+	}{
+		receiptDoc: doc,
+		PaperMM:    paperMM,
+		QRImage:    template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png)),
+	}
+
+	var buf bytes.Buffer
+	if err := receiptTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderReceiptPDF membuat struk PDF selebar kertas thermal
+func renderReceiptPDF(doc receiptDoc, paperMM int) ([]byte, error) {
+	const margin, lineHeight = 3.0, 4.0
+	width := float64(paperMM)
+	contentWidth := width - 2*margin
+	qrSize := width * 0.6
+
+	// Ukuran halaman PDF harus tetap, jadi hitung dulu jumlah baris setelah teks panjang dipotong
+	measure := gofpdf.New("P", "mm", "A4", "")
+	measure.SetFont("Courier", "B", 10)
+	tr := measure.UnicodeTranslatorFromDescriptor("")
+	rows := 2
+	for _, line := range doc.Lines {
+		if line.Separator || line.Right != "" {
+			rows++
+			continue
+		}
+		rows += len(measure.SplitText(tr(line.Left), contentWidth))
+	}
+	height := 2*margin + float64(rows)*lineHeight + qrSize
+
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		UnitStr: "mm",
+		Size:    gofpdf.SizeType{Wd: width, Ht: height},
+	})
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, margin)
+	pdf.AddPage()
+
+	for _, line := range doc.Lines {
+		if line.Separator {
+			y := pdf.GetY() + lineHeight/2
+			pdf.SetDashPattern([]float64{1, 1}, 0)
+			pdf.Line(margin, y, width-margin, y)
+			pdf.Ln(lineHeight)
+			continue
+		}
+
+		style := ""
+		if line.Bold {
+			style = "B"
+		}
+		size := 7.0
+		if line.Large {
+			size = 10
+		}
+		pdf.SetFont("Courier", style, size)
+
+		align := "L"
+		if line.Center {
+			align = "C"
+		}
+		if line.Right != "" {
+			pdf.CellFormat(contentWidth/2, lineHeight, tr(line.Left), "", 0, "L", false, 0, "")
+			pdf.CellFormat(contentWidth/2, lineHeight, tr(line.Right), "", 1, "R", false, 0, "")
+		} else {
+			pdf.MultiCell(contentWidth, lineHeight, tr(line.Left), "", align, false)
+		}
+	}
+
+	png, err := qrcode.Encode(doc.QRData, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+	pdf.RegisterImageOptionsReader("qr", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+	pdf.ImageOptions("qr", (width-qrSize)/2, pdf.GetY()+1, qrSize, qrSize, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.SetY(pdf.GetY() + qrSize + 1)
+	pdf.SetFont("Courier", "", 7)
+	pdf.CellFormat(contentWidth, lineHeight, doc.QRData, "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
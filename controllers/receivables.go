@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Kelompok umur piutang dalam hari sejak tanggal order
+const (
+	AgingCurrent = "0-7"
+	AgingMonth   = "8-30"
+	AgingOverdue = "30+"
+)
+
+// agingBucket menentukan kelompok umur piutang
+func agingBucket(days int) string {
+	switch {
+	case days <= 7:
+		return AgingCurrent
+	case days <= 30:
+		return AgingMonth
+	default:
+		return AgingOverdue
+	}
+}
+
+// ReceivableTransaction adalah satu transaksi yang masih memiliki sisa tagihan
+type ReceivableTransaction struct {
+	TransactionID   primitive.ObjectID `json:"transactionId" bson:"_id"`
+	OrderNumber     string             `json:"orderNumber" bson:"orderNumber"`
+	CustomerID      primitive.ObjectID `json:"customerId" bson:"customerId"`
+	TransactionDate time.Time          `json:"transactionDate" bson:"transactionDate"`
+	Status          string             `json:"status" bson:"status"`
+	TotalAmount     float64            `json:"totalAmount" bson:"totalAmount"`
+	AmountPaid      float64            `json:"amountPaid" bson:"amountPaid"`
+	Outstanding     float64            `json:"outstanding" bson:"outstanding"`
+	Customer        models.Customer    `json:"-" bson:"customer"`
+	AgeDays         int                `json:"ageDays" bson:"-"`
+	Bucket          string             `json:"bucket" bson:"-"`
+}
+
+// ReceivableCustomer adalah total piutang seorang customer beserta transaksinya
+type ReceivableCustomer struct {
+	CustomerID   primitive.ObjectID      `json:"customerId"`
+	FullName     string                  `json:"fullName"`
+	PhoneNumber  string                  `json:"phoneNumber"`
+	Outstanding  float64                 `json:"outstanding"`
+	Buckets      map[string]float64      `json:"buckets"`
+	OldestDays   int                     `json:"oldestDays"`
+	Transactions []ReceivableTransaction `json:"transactions"`
+}
+
+// ReceivablesReport adalah laporan umur piutang
+type ReceivablesReport struct {
+	AsOf         time.Time            `json:"asOf"`
+	Outstanding  float64              `json:"outstanding"`
+	Transactions int                  `json:"transactions"`
+	Buckets      map[string]float64   `json:"buckets"`
+	Customers    []ReceivableCustomer `json:"customers"`
+}
+
+func emptyAgingBuckets() map[string]float64 {
+	return map[string]float64{AgingCurrent: 0, AgingMonth: 0, AgingOverdue: 0}
+}
+
+// findReceivables mencari transaksi yang belum lunas. Jumlah terbayar dihitung langsung dari koleksi payments
+// agar laporan tetap benar walaupun amountPaid di transaksi belum diperbarui.
+func findReceivables(ctx context.Context, filter bson.M) ([]ReceivableTransaction, error) {
+	filter["totalAmount"] = bson.M{"$gt": 0}
+	filter["paymentStatus"] = bson.M{"$ne": models.PaymentPaid}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": config.PaymentCollection.Name(),
+			"let":  bson.M{"transactionId": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"$expr":  bson.M{"$eq": bson.A{"$transactionId", "$$transactionId"}},
+					"status": bson.M{"$in": collectedPaymentStatuses},
+				}},
+				bson.M{"$group": bson.M{"_id": nil, "paid": bson.M{"$sum": netPaymentAmount}}},
+			},
+			"as": "payments",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         config.CustomerCollection.Name(),
+			"localField":   "customerId",
+			"foreignField": "_id",
+			"as":           "customers",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"orderNumber":     1,
+			"customerId":      1,
+			"transactionDate": 1,
+			"status":          1,
+			"totalAmount":     1,
+			"amountPaid":      bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$payments.paid", 0}}, 0}},
+			// Data customer terbaru, atau salinan yang tersimpan di transaksi jika customer sudah dihapus
+			"customer": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$customers", 0}}, "$customer"}},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"outstanding": bson.M{"$subtract": bson.A{"$totalAmount", "$amountPaid"}}}}},
+		{{Key: "$match", Value: bson.M{"outstanding": bson.M{"$gt": 0}}}},
+		{{Key: "$sort", Value: bson.M{"transactionDate": 1}}},
+	}
+
+	receivables := []ReceivableTransaction{}
+	if err := aggregateAll(ctx, config.TransactionCollection, pipeline, &receivables); err != nil {
+		return nil, err
+	}
+	return receivables, nil
+}
+
+// buildReceivablesReport mengelompokkan piutang per customer dan per umur
+func buildReceivablesReport(receivables []ReceivableTransaction, asOf time.Time) ReceivablesReport {
+	report := ReceivablesReport{AsOf: asOf, Buckets: emptyAgingBuckets(), Customers: []ReceivableCustomer{}}
+	customers := map[primitive.ObjectID]*ReceivableCustomer{}
+	order := []primitive.ObjectID{}
+
+	for _, receivable := range receivables {
+		receivable.AgeDays = int(asOf.Sub(receivable.TransactionDate).Hours() / 24)
+		if receivable.AgeDays < 0 {
+			receivable.AgeDays = 0
+		}
+		receivable.Bucket = agingBucket(receivable.AgeDays)
+
+		customer, ok := customers[receivable.CustomerID]
+		if !ok {
+			customer = &ReceivableCustomer{
+				CustomerID:  receivable.CustomerID,
+				FullName:    receivable.Customer.FullName,
+				PhoneNumber: receivable.Customer.PhoneNumber,
+				Buckets:     emptyAgingBuckets(),
+			}
+			customers[receivable.CustomerID] = customer
+			order = append(order, receivable.CustomerID)
+		}
+		customer.Outstanding += receivable.Outstanding
+		customer.Buckets[receivable.Bucket] += receivable.Outstanding
+		if receivable.AgeDays > customer.OldestDays {
+			customer.OldestDays = receivable.AgeDays
+		}
+		customer.Transactions = append(customer.Transactions, receivable)
+
+		report.Outstanding += receivable.Outstanding
+		report.Buckets[receivable.Bucket] += receivable.Outstanding
+		report.Transactions++
+	}
+
+	for _, id := range order {
+		report.Customers = append(report.Customers, *customers[id])
+	}
+	// Customer dengan piutang terbesar ditagih lebih dulu
+	sort.SliceStable(report.Customers, func(i, j int) bool {
+		return report.Customers[i].Outstanding > report.Customers[j].Outstanding
+	})
+	return report
+}
+
+// Fungsi untuk laporan piutang: transaksi yang belum lunas dikelompokkan per customer dan umur (0-7, 8-30, 30+ hari).
+// Parameter customerId dan bucket opsional untuk mempersempit daftar.
+func GetReceivablesReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := bson.M{}
+	if id := query.Get("customerId"); id != "" {
+		customerID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			http.Error(w, "ID customer tidak valid", http.StatusBadRequest)
+			return
+		}
+		filter["customerId"] = customerID
+	}
+
+	// "+" di query string terbaca sebagai spasi jika tidak di-encode, sehingga "30" juga diterima
+	now := time.Now()
+	switch bucket := strings.TrimSpace(query.Get("bucket")); bucket {
+	case "":
+	case AgingCurrent:
+		filter["transactionDate"] = bson.M{"$gt": now.AddDate(0, 0, -8)}
+	case AgingMonth:
+		filter["transactionDate"] = bson.M{"$lte": now.AddDate(0, 0, -8), "$gt": now.AddDate(0, 0, -31)}
+	case AgingOverdue, "30":
+		filter["transactionDate"] = bson.M{"$lte": now.AddDate(0, 0, -31)}
+	default:
+		http.Error(w, "bucket harus 0-7, 8-30, atau 30+", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	receivables, err := findReceivables(ctx, filter)
+	if err != nil {
+		http.Error(w, "Gagal membuat laporan piutang", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildReceivablesReport(receivables, now))
+}
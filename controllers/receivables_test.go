@@ -0,0 +1,23 @@
+package controllers
+
+import "testing"
+
+func TestAgingBucket(t *testing.T) {
+	tests := []struct {
+		days int
+		want string
+	}{
+		{0, AgingCurrent},
+		{7, AgingCurrent},
+		{8, AgingMonth},
+		{30, AgingMonth},
+		{31, AgingOverdue},
+		{365, AgingOverdue},
+	}
+
+	for _, tt := range tests {
+		if got := agingBucket(tt.days); got != tt.want {
+			t.Errorf("agingBucket(%d) = %q, want %q", tt.days, got, tt.want)
+		}
+	}
+}
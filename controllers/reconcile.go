@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/services"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Batas jumlah pembayaran yang diperiksa dalam satu kali jalan agar tidak melewati timeout cron
+const reconcileBatchSize = 100
+
+// Jeda paling lama antara dua pemeriksaan pembayaran yang sama
+const reconcileMaxBackoff = 6 * time.Hour
+
+// PaymentMismatch adalah perbedaan antara data pembayaran di database dan di gateway
+type PaymentMismatch struct {
+	OrderID       string  `json:"order_id"`
+	Channel       string  `json:"channel"`
+	Kind          string  `json:"kind"` // status, amount, atau missing
+	DBStatus      string  `json:"db_status"`
+	GatewayStatus string  `json:"gateway_status,omitempty"`
+	DBAmount      float64 `json:"db_amount"`
+	GatewayAmount float64 `json:"gateway_amount,omitempty"`
+	Resolved      bool    `json:"resolved"` // true jika status di database sudah disamakan dengan gateway
+	Error         string  `json:"error,omitempty"`
+}
+
+// ReconcileJobResult adalah ringkasan satu kali jalannya job rekonsiliasi pembayaran
+type ReconcileJobResult struct {
+	Checked    int               `json:"checked"`
+	Updated    int               `json:"updated"` // Status diperbarui dari gateway (webhook yang terlewat)
+	Expired    int               `json:"expired"` // Link yang lewat batas waktu tetapi belum kedaluwarsa di gateway
+	Failed     int               `json:"failed"`
+	Mismatches []PaymentMismatch `json:"mismatches"`
+}
+
+// reconcileMinAge membaca PAYMENT_RECONCILE_MINUTES, default 15 menit agar webhook sempat datang lebih dulu
+func reconcileMinAge() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("PAYMENT_RECONCILE_MINUTES")); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return 15 * time.Minute
+}
+
+// RunReconcileJob menanyakan status pembayaran online yang masih pending lebih dari minAge ke gateway,
+// menyimpan status terbaru, mengedaluwarsakan link yang sudah lewat batas waktu, dan melaporkan selisih data.
+// Dipakai oleh endpoint cron maupun perintah CLI.
+func RunReconcileJob(ctx context.Context, now time.Time, minAge time.Duration) (ReconcileJobResult, error) {
+	result := ReconcileJobResult{Mismatches: []PaymentMismatch{}}
+	manualChannels := []string{}
+	for channel := range manualPaymentChannels {
+		manualChannels = append(manualChannels, channel)
+	}
+
+	// Pembayaran yang terus gagal diperiksa dijadwalkan makin jarang agar tidak memenuhi setiap batch,
+	// pembayaran yang belum pernah diperiksa (tanpa next_reconcile_at) didahulukan
+	filter := bson.M{
+		"status":     bson.M{"$in": pendingPaymentStatuses},
+		"channel":    bson.M{"$nin": manualChannels},
+		"created_at": bson.M{"$lte": now.Add(-minAge)},
+		"$or": bson.A{
+			bson.M{"next_reconcile_at": bson.M{"$exists": false}},
+			bson.M{"next_reconcile_at": bson.M{"$lte": now}},
+		},
+	}
+	sort := bson.D{{Key: "next_reconcile_at", Value: 1}, {Key: "created_at", Value: 1}}
+	opts := options.Find().SetSort(sort).SetLimit(reconcileBatchSize)
+	cursor, err := config.PaymentCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return result, err
+	}
+	var payments []models.Payment
+	if err := cursor.All(ctx, &payments); err != nil {
+		return result, err
+	}
+
+	expiry := services.LoadSnapConfig().Expiry
+	for _, payment := range payments {
+		result.Checked++
+		reconcilePayment(ctx, payment, now, expiry, &result)
+		scheduleNextReconcile(ctx, payment, now, minAge)
+	}
+	return result, nil
+}
+
+// reconcileBackoff menghitung jeda sebelum pemeriksaan ke-(attempts+1): minAge, 2x, 4x, ... paling lama reconcileMaxBackoff
+func reconcileBackoff(attempts int, minAge time.Duration) time.Duration {
+	backoff := minAge
+	for i := 1; i < attempts && backoff < reconcileMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > reconcileMaxBackoff {
+		return reconcileMaxBackoff
+	}
+	return backoff
+}
+
+// scheduleNextReconcile mencatat pemeriksaan dan jadwal berikutnya. Pembayaran yang sudah tidak pending
+// tidak lagi diambil job sehingga jadwalnya tidak berpengaruh.
+func scheduleNextReconcile(ctx context.Context, payment models.Payment, now time.Time, minAge time.Duration) {
+	attempts := payment.ReconcileAttempts + 1
+	next := now.Add(reconcileBackoff(attempts, minAge))
+	update := bson.M{"$set": bson.M{"reconcile_attempts": attempts, "next_reconcile_at": next}}
+	config.PaymentCollection.UpdateOne(ctx, bson.M{"_id": payment.ID}, update)
+}
+
+// reconcilePayment mencocokkan satu pembayaran pending dengan gateway
+func reconcilePayment(ctx context.Context, payment models.Payment, now time.Time, expiry time.Duration, result *ReconcileJobResult) {
+	mismatch := PaymentMismatch{
+		OrderID:  payment.OrderID,
+		Channel:  payment.Channel,
+		DBStatus: payment.Status,
+		DBAmount: payment.GrossAmount,
+	}
+
+	// Link dianggap basi jika sudah lewat expires_at (atau umur link untuk pembayaran lama tanpa expires_at)
+	stale := payment.CreatedAt.Add(expiry).Before(now)
+	if payment.ExpiresAt != nil {
+		stale = payment.ExpiresAt.Before(now)
+	}
+
+	gateway, err := paymentGateway(payment)
+	if err != nil {
+		result.Failed++
+		mismatch.Kind = "missing"
+		mismatch.Error = err.Error()
+		result.Mismatches = append(result.Mismatches, mismatch)
+		return
+	}
+
+	status, err := gateway.Status(ctx, payment.OrderID)
+	if err != nil {
+		// Gateway tidak mengenal order ini (mis. customer tidak pernah membuka link), cukup kedaluwarsakan jika sudah basi
+		mismatch.Kind = "missing"
+		mismatch.Error = err.Error()
+		if stale {
+			if outcome, err := applyGatewayStatus(ctx, payment.OrderID, services.GatewayExpire); err != nil {
+				result.Failed++
+			} else if outcome == models.WebhookApplied {
+				mismatch.Resolved = true
+				result.Expired++
+			}
+		}
+		result.Mismatches = append(result.Mismatches, mismatch)
+		return
+	}
+	mismatch.GatewayStatus = status.Status
+	mismatch.GatewayAmount = status.GrossAmount
+
+	if status.GrossAmount > 0 && math.Round(status.GrossAmount) != math.Round(payment.GrossAmount) {
+		amountMismatch := mismatch
+		amountMismatch.Kind = "amount"
+		result.Mismatches = append(result.Mismatches, amountMismatch)
+	}
+
+	if status.Status == services.GatewayPending {
+		if !stale {
+			return
+		}
+		// Gateway belum mengedaluwarsakan link yang sudah lewat batas, batalkan agar tidak bisa dibayar lagi
+		if err := gateway.Cancel(ctx, payment.OrderID); err != nil {
+			result.Failed++
+			return
+		}
+		outcome, err := applyGatewayStatus(ctx, payment.OrderID, services.GatewayExpire)
+		if err != nil {
+			result.Failed++
+			return
+		}
+		if outcome == models.WebhookApplied {
+			result.Expired++
+		}
+		return
+	}
+
+	// Webhook terlewat: status di gateway sudah berubah tetapi database masih pending
+	mismatch.Kind = "status"
+	// Hasil "ignored" berarti webhook datang lebih dulu atau status database sudah lebih baru
+	if outcome, err := applyGatewayStatus(ctx, payment.OrderID, status.Status); err != nil {
+		result.Failed++
+		mismatch.Error = err.Error()
+	} else if outcome == models.WebhookApplied {
+		mismatch.Resolved = true
+		result.Updated++
+	}
+	result.Mismatches = append(result.Mismatches, mismatch)
+}
+
+// Fungsi untuk menjalankan rekonsiliasi pembayaran dari Vercel Cron atau dipanggil manual oleh admin.
+// Parameter older_than (menit) opsional untuk mengganti PAYMENT_RECONCILE_MINUTES.
+func RunPaymentReconciliation(w http.ResponseWriter, r *http.Request) {
+	minAge := reconcileMinAge()
+	if value := r.URL.Query().Get("older_than"); value != "" {
+		// Nilai 0 membuat pembayaran pending ditanyakan ulang setiap kali job berjalan
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes <= 0 {
+			http.Error(w, "older_than harus berupa jumlah menit lebih dari 0", http.StatusBadRequest)
+			return
+		}
+		minAge = time.Duration(minutes) * time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Second)
+	defer cancel()
+
+	result, err := RunReconcileJob(ctx, time.Now(), minAge)
+	if err != nil {
+		http.Error(w, "Gagal menjalankan rekonsiliasi pembayaran: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,414 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/services"
+	"laundry-pos/utils"
+	"math"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// refundReasons adalah kode alasan refund yang diterima
+var refundReasons = map[string]bool{
+	models.RefundReasonDamaged:    true,
+	models.RefundReasonLost:       true,
+	models.RefundReasonCancelled:  true,
+	models.RefundReasonOvercharge: true,
+	models.RefundReasonDuplicate:  true,
+	models.RefundReasonOther:      true,
+}
+
+// Fungsi untuk mengajukan pengembalian dana (penuh atau sebagian) atas sebuah pembayaran
+func CreateRefund(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PaymentID  primitive.ObjectID `json:"paymentId"`
+		Amount     float64            `json:"amount"` // Kosong berarti seluruh sisa yang bisa direfund
+		ReasonCode string             `json:"reasonCode"`
+		Note       string             `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if !refundReasons[req.ReasonCode] {
+		http.Error(w, "Kode alasan refund tidak valid", http.StatusBadRequest)
+		return
+	}
+	if req.ReasonCode == models.RefundReasonOther && req.Note == "" {
+		http.Error(w, "Catatan wajib diisi untuk alasan lainnya", http.StatusBadRequest)
+		return
+	}
+	if req.Amount < 0 {
+		http.Error(w, "Jumlah refund tidak boleh negatif", http.StatusBadRequest)
+		return
+	}
+
+	requestedBy, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Pengguna tidak dikenali", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var payment models.Payment
+	if err := config.PaymentCollection.FindOne(ctx, bson.M{"_id": req.PaymentID}).Decode(&payment); err != nil {
+		http.Error(w, "Pembayaran tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	if !isCollectedStatus(payment.Status) {
+		http.Error(w, "Hanya pembayaran yang sudah diterima yang bisa direfund", http.StatusBadRequest)
+		return
+	}
+
+	refundable, err := refundableAmount(ctx, payment)
+	if err != nil {
+		http.Error(w, "Gagal menghitung jumlah yang bisa direfund", http.StatusInternalServerError)
+		return
+	}
+	amount := math.Round(req.Amount)
+	if amount == 0 {
+		amount = refundable
+	}
+	if amount <= 0 || amount > refundable {
+		http.Error(w, fmt.Sprintf("Jumlah refund melebihi sisa yang bisa direfund %s", utils.FormatRupiah(refundable)), http.StatusBadRequest)
+		return
+	}
+
+	refund := models.Refund{
+		PaymentID:     payment.ID,
+		TransactionID: payment.TransactionID,
+		OrderID:       payment.OrderID,
+		Amount:        amount,
+		ReasonCode:    req.ReasonCode,
+		Note:          req.Note,
+		Method:        refundMethodFor(payment),
+		Status:        models.RefundRequested,
+		RequestedBy:   requestedBy,
+		CreatedAt:     time.Now(),
+	}
+	result, err := config.RefundCollection.InsertOne(ctx, refund)
+	if err != nil {
+		http.Error(w, "Gagal menyimpan pengajuan refund", http.StatusInternalServerError)
+		return
+	}
+	refund.ID = result.InsertedID.(primitive.ObjectID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Pengajuan refund berhasil dibuat, menunggu persetujuan admin",
+		"refund":  refund,
+	})
+}
+
+// Fungsi untuk mendapatkan daftar refund, bisa difilter ?status= dan ?transactionId=
+func GetRefunds(w http.ResponseWriter, r *http.Request) {
+	filter := bson.M{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		filter["status"] = status
+	}
+	if id := r.URL.Query().Get("transactionId"); id != "" {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			http.Error(w, "ID transaksi tidak valid", http.StatusBadRequest)
+			return
+		}
+		filter["transactionId"] = objID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := config.RefundCollection.Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, "Gagal mengambil data refund", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	refunds := []models.Refund{}
+	if err := cursor.All(ctx, &refunds); err != nil {
+		http.Error(w, "Gagal memproses data refund", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refunds)
+}
+
+// Fungsi untuk menyetujui atau menolak pengajuan refund (khusus admin).
+// Refund yang gagal di gateway boleh disetujui ulang, refund key yang sama mencegah dana keluar dua kali.
+func DecideRefund(w http.ResponseWriter, r *http.Request) {
+	objID, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Approve bool   `json:"approve"`
+		Note    string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	adminID, _ := currentUserID(r)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Klaim pengajuan secara atomik agar dua admin tidak memproses refund yang sama bersamaan
+	now := time.Now()
+	status := models.RefundRejected
+	if req.Approve {
+		status = models.RefundApproved
+	}
+	filter := bson.M{"_id": objID, "status": bson.M{"$in": []string{models.RefundRequested, models.RefundFailed}}}
+	update := bson.M{"$set": bson.M{
+		"status":       status,
+		"approvedBy":   adminID,
+		"decisionNote": req.Note,
+		"decidedAt":    now,
+	}}
+	var refund models.Refund
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = config.RefundCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&refund)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "Refund tidak ditemukan atau sudah diproses", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Gagal memperbarui refund", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Approve {
+		if err := executeRefund(ctx, &refund); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": "Refund disetujui tetapi gagal diproses: " + err.Error(),
+				"refund":  refund,
+			})
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Refund berhasil diproses",
+		"refund":  refund,
+	})
+}
+
+// executeRefund mengembalikan dana lewat gateway atau mencatat uang tunai yang diserahkan,
+// lalu memperbarui jumlah refund pada pembayaran dan status pelunasan transaksi
+func executeRefund(ctx context.Context, refund *models.Refund) error {
+	var payment models.Payment
+	if err := config.PaymentCollection.FindOne(ctx, bson.M{"_id": refund.PaymentID}).Decode(&payment); err != nil {
+		return markRefundFailed(ctx, refund, fmt.Errorf("pembayaran tidak ditemukan"))
+	}
+	if payment.GrossAmount-payment.RefundedAmount < refund.Amount {
+		return markRefundFailed(ctx, refund, fmt.Errorf("jumlah refund melebihi sisa pembayaran"))
+	}
+
+	// Uang tunai diambil dari laci shift pengaju, atau shift admin yang menyetujui
+	if refund.Method == models.RefundMethodCashOut {
+		refund.ShiftID = openShiftID(ctx, refund.RequestedBy)
+		if refund.ShiftID.IsZero() {
+			refund.ShiftID = openShiftID(ctx, refund.ApprovedBy)
+		}
+		if refund.ShiftID.IsZero() {
+			return markRefundFailed(ctx, refund, fmt.Errorf("tidak ada shift kasir yang terbuka untuk mengeluarkan uang tunai"))
+		}
+	}
+
+	if refund.Method == models.RefundMethodGateway {
+		refund.RefundKey = refund.ID.Hex()
+		reason := refund.ReasonCode
+		if refund.Note != "" {
+			reason += ": " + refund.Note
+		}
+		gateway, err := paymentGateway(payment)
+		if err != nil {
+			return markRefundFailed(ctx, refund, err)
+		}
+		if err := gateway.Refund(ctx, payment.OrderID, refund.RefundKey, refund.Amount, reason); err != nil {
+			return markRefundFailed(ctx, refund, err)
+		}
+	}
+
+	// Hanya tambah jumlah refund jika masih cukup, mencegah refund melebihi nilai pembayaran
+	paymentFilter := bson.M{
+		"_id":   payment.ID,
+		"$expr": bson.M{"$gte": bson.A{netPaymentAmount, refund.Amount}},
+	}
+	result, err := config.PaymentCollection.UpdateOne(ctx, paymentFilter, bson.M{"$inc": bson.M{"refunded_amount": refund.Amount}})
+	if err != nil {
+		return markRefundFailed(ctx, refund, err)
+	}
+	if result.MatchedCount == 0 {
+		return markRefundFailed(ctx, refund, fmt.Errorf("jumlah refund melebihi sisa pembayaran"))
+	}
+
+	now := time.Now()
+	refund.Status = models.RefundCompleted
+	refund.CompletedAt = &now
+	refund.LastError = ""
+	update := bson.M{
+		"$set":   bson.M{"status": refund.Status, "completedAt": now, "refundKey": refund.RefundKey, "shiftId": refund.ShiftID},
+		"$unset": bson.M{"lastError": ""},
+	}
+	if _, err := config.RefundCollection.UpdateOne(ctx, bson.M{"_id": refund.ID}, update); err != nil {
+		return err
+	}
+
+	_, err = refreshPaymentStatus(ctx, refund.TransactionID)
+	return err
+}
+
+// markRefundFailed menyimpan alasan kegagalan refund agar bisa disetujui ulang
+func markRefundFailed(ctx context.Context, refund *models.Refund, cause error) error {
+	refund.Status = models.RefundFailed
+	refund.LastError = cause.Error()
+	update := bson.M{"$set": bson.M{"status": refund.Status, "lastError": refund.LastError}}
+	config.RefundCollection.UpdateOne(ctx, bson.M{"_id": refund.ID}, update)
+	return cause
+}
+
+// refundableAmount menghitung sisa pembayaran yang bisa direfund setelah dikurangi refund yang masih diajukan
+func refundableAmount(ctx context.Context, payment models.Payment) (float64, error) {
+	cursor, err := config.RefundCollection.Find(ctx, bson.M{
+		"paymentId": payment.ID,
+		"status":    bson.M{"$in": []string{models.RefundRequested, models.RefundApproved, models.RefundFailed}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var pending []models.Refund
+	if err := cursor.All(ctx, &pending); err != nil {
+		return 0, err
+	}
+
+	refundable := payment.GrossAmount - payment.RefundedAmount
+	for _, refund := range pending {
+		refundable -= refund.Amount
+	}
+	return math.Max(refundable, 0), nil
+}
+
+// refundMethodFor menentukan refund lewat payment gateway atau uang tunai dari kasir
+func refundMethodFor(payment models.Payment) string {
+	if manualPaymentChannels[payment.Channel] {
+		return models.RefundMethodCashOut
+	}
+	return models.RefundMethodGateway
+}
+
+// paymentGateway mengembalikan gateway tempat pembayaran dibuat, bukan gateway yang sedang aktif di toko.
+// Pembayaran lama tidak memiliki channel dan semuanya berasal dari Midtrans.
+func paymentGateway(payment models.Payment) (services.PaymentGateway, error) {
+	provider := payment.Channel
+	if provider == "" {
+		provider = services.ProviderMidtrans
+	}
+	return services.NewPaymentGateway(provider)
+}
+
+// Fungsi untuk membatalkan (void) pembayaran: pembayaran online yang belum settle dibatalkan di gateway,
+// pembayaran manual yang salah catat ditandai void. Pembayaran yang sudah settle di gateway harus lewat refund.
+func VoidPayment(w http.ResponseWriter, r *http.Request) {
+	objID, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Reason == "" {
+		http.Error(w, "Alasan pembatalan wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var payment models.Payment
+	if err := config.PaymentCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&payment); err != nil {
+		http.Error(w, "Pembayaran tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	if payment.RefundedAmount > 0 {
+		http.Error(w, "Pembayaran yang sudah direfund tidak bisa dibatalkan", http.StatusBadRequest)
+		return
+	}
+
+	var newStatus string
+	switch refundMethodFor(payment) {
+	case models.RefundMethodGateway:
+		if isCollectedStatus(payment.Status) {
+			http.Error(w, "Pembayaran online yang sudah diterima harus dikembalikan lewat refund", http.StatusBadRequest)
+			return
+		}
+		if payment.Status == "cancel" || payment.Status == "expire" {
+			http.Error(w, "Pembayaran sudah tidak aktif", http.StatusBadRequest)
+			return
+		}
+		gateway, err := paymentGateway(payment)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Tagihan yang belum tercatat di gateway (link belum dibuka customer) dianggap sudah batal oleh Cancel
+		if err := gateway.Cancel(ctx, payment.OrderID); err != nil {
+			http.Error(w, "Gagal membatalkan pembayaran di gateway: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		newStatus = "cancel"
+	default:
+		if payment.Status == "void" {
+			http.Error(w, "Pembayaran sudah dibatalkan", http.StatusBadRequest)
+			return
+		}
+		newStatus = "void"
+	}
+
+	update := bson.M{"$set": bson.M{"status": newStatus, "note": req.Reason}}
+	if _, err := config.PaymentCollection.UpdateOne(ctx, bson.M{"_id": payment.ID}, update); err != nil {
+		http.Error(w, "Gagal membatalkan pembayaran", http.StatusInternalServerError)
+		return
+	}
+
+	transaction, err := refreshPaymentStatus(ctx, payment.TransactionID)
+	if err != nil {
+		http.Error(w, "Gagal memperbarui status pelunasan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Pembayaran berhasil dibatalkan",
+		"status":        newStatus,
+		"amountPaid":    transaction.AmountPaid,
+		"outstanding":   outstandingBalance(transaction),
+		"paymentStatus": transaction.PaymentStatus,
+	})
+}
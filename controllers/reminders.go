@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/services"
+	"laundry-pos/utils"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReminderJobResult adalah ringkasan satu kali jalannya job pengingat
+type ReminderJobResult struct {
+	UncollectedReminders int          `json:"uncollectedReminders"`
+	UnpaidReminders      int          `json:"unpaidReminders"`
+	StorageFeesUpdated   int          `json:"storageFeesUpdated"`
+	AlreadyReminded      int          `json:"alreadyReminded"` // Dilewati karena pengingat yang sama sudah pernah dibuat
+	Outbox               outboxResult `json:"outbox"`
+}
+
+// RunReminderJob mencari cucian yang lama tidak diambil atau belum dibayar, menghitung biaya penyimpanan,
+// dan mengantrekan pengingat. Dipakai oleh endpoint cron maupun perintah CLI.
+func RunReminderJob(ctx context.Context, now time.Time) (ReminderJobResult, error) {
+	var result ReminderJobResult
+	settings := loadShopSettings(ctx)
+
+	if err := remindUncollected(ctx, now, settings, &result); err != nil {
+		return result, err
+	}
+	if err := remindUnpaid(ctx, now, settings, &result); err != nil {
+		return result, err
+	}
+
+	// Sekalian kirim ulang notifikasi yang sebelumnya gagal
+	outbox, err := processOutbox(ctx, 50)
+	if err != nil {
+		return result, err
+	}
+	result.Outbox = outbox
+	return result, nil
+}
+
+// remindUncollected menangani cucian berstatus Ready yang belum diambil setelah N hari
+func remindUncollected(ctx context.Context, now time.Time, settings models.ShopSettings, result *ReminderJobResult) error {
+	interval := settings.UncollectedReminderDays
+	transactions, err := findTransactions(ctx, bson.M{
+		"status":  models.TransactionReady,
+		"readyAt": bson.M{"$lte": now.AddDate(0, 0, -interval)},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		days := int(now.Sub(*transaction.ReadyAt).Hours() / 24)
+
+		// Biaya penyimpanan dihitung ulang dari awal sehingga aman jika job berjalan berkali-kali
+		if settings.StorageFeePerDay > 0 && days > settings.StorageFeeAfterDays {
+			fee := float64(days-settings.StorageFeeAfterDays) * settings.StorageFeePerDay
+			if fee != transaction.StorageFee {
+				transaction.StorageFee = fee
+				recalculateTotal(&transaction)
+				update := bson.M{"$set": bson.M{"storageFee": transaction.StorageFee, "totalAmount": transaction.TotalAmount}}
+				if _, err := config.TransactionCollection.UpdateOne(ctx, bson.M{"_id": transaction.ID}, update); err != nil {
+					return err
+				}
+				// Total berubah sehingga status pelunasan juga harus dihitung ulang
+				if _, err := refreshPaymentStatus(ctx, transaction.ID); err != nil {
+					return err
+				}
+				result.StorageFeesUpdated++
+			}
+		}
+
+		// Satu pengingat untuk setiap kelipatan N hari
+		claimed, err := claimReminder(ctx, models.ReminderUncollected, transaction.ID, days/interval, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			result.AlreadyReminded++
+			continue
+		}
+
+		data := services.NotificationData{DaysWaiting: days}
+		if transaction.StorageFee > 0 {
+			data.StorageFee = utils.FormatRupiah(transaction.StorageFee)
+		}
+		queueNotification(ctx, transaction, services.EventUncollectedReminder, data)
+		result.UncollectedReminders++
+	}
+	return nil
+}
+
+// Transaksi yang lebih lama dari ini tidak lagi diingatkan, penagihannya ditangani lewat laporan piutang
+const unpaidReminderMaxDays = 90
+
+// remindUnpaid menangani transaksi yang belum dibayar setelah M hari
+func remindUnpaid(ctx context.Context, now time.Time, settings models.ShopSettings, result *ReminderJobResult) error {
+	interval := settings.UnpaidReminderDays
+	transactions, err := findTransactions(ctx, bson.M{
+		"transactionDate": bson.M{"$gte": now.AddDate(0, 0, -unpaidReminderMaxDays), "$lte": now.AddDate(0, 0, -interval)},
+		"totalAmount":     bson.M{"$gt": 0},
+		"paymentStatus":   bson.M{"$ne": models.PaymentPaid},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		// Hanya transaksi yang belum lunas yang dihitung ulang, untuk transaksi lama yang belum memiliki paymentStatus
+		transaction, err := refreshPaymentStatus(ctx, transaction.ID)
+		if err != nil {
+			return err
+		}
+		if transaction.PaymentStatus == models.PaymentPaid {
+			continue
+		}
+
+		days := int(now.Sub(transaction.TransactionDate).Hours() / 24)
+		claimed, err := claimReminder(ctx, models.ReminderUnpaid, transaction.ID, days/interval, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			result.AlreadyReminded++
+			continue
+		}
+
+		queueNotification(ctx, transaction, services.EventUnpaidReminder, services.NotificationData{
+			Amount:      utils.FormatRupiah(outstandingBalance(transaction)),
+			DaysWaiting: days,
+		})
+		result.UnpaidReminders++
+	}
+	return nil
+}
+
+// claimReminder mencatat pengingat dengan kunci unik, false jika pengingat yang sama sudah ada
+func claimReminder(ctx context.Context, kind string, transactionID primitive.ObjectID, sequence int, now time.Time) (bool, error) {
+	reminder := models.Reminder{
+		ID:            fmt.Sprintf("%s:%s:%d", kind, transactionID.Hex(), sequence),
+		TransactionID: transactionID,
+		Kind:          kind,
+		Sequence:      sequence,
+		CreatedAt:     now,
+	}
+	_, err := config.ReminderCollection.InsertOne(ctx, reminder)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Fungsi untuk menjalankan job pengingat dari Vercel Cron atau dipanggil manual oleh admin
+func RunReminders(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Second)
+	defer cancel()
+
+	result, err := RunReminderJob(ctx, time.Now())
+	if err != nil {
+		http.Error(w, "Gagal menjalankan job pengingat: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,364 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/utils"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Rentang laporan paling panjang agar agregasi tidak memindai seluruh koleksi
+const reportMaxDays = 366
+
+// Format periode $dateToString untuk setiap pengelompokan laporan
+var reportGroupFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%G-W%V", // Minggu ISO, misalnya 2026-W42
+	"month": "%Y-%m",
+}
+
+// reportRange adalah rentang tanggal laporan dalam zona waktu toko, End tidak termasuk
+type reportRange struct {
+	From     time.Time
+	End      time.Time
+	Timezone string // Offset untuk agregasi Mongo, misalnya "+07:00"
+}
+
+// parseReportRange membaca parameter from dan to (YYYY-MM-DD). Jika kosong, laporan memakai hari ini.
+func parseReportRange(r *http.Request, loc *time.Location, now time.Time) (reportRange, error) {
+	today := now.In(loc)
+	from := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	to := from
+
+	query := r.URL.Query()
+	if value := query.Get("from"); value != "" {
+		day, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return reportRange{}, fmt.Errorf("Format tanggal from harus YYYY-MM-DD")
+		}
+		from, to = day, day
+	}
+	if value := query.Get("to"); value != "" {
+		day, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return reportRange{}, fmt.Errorf("Format tanggal to harus YYYY-MM-DD")
+		}
+		to = day
+	}
+	if to.Before(from) {
+		return reportRange{}, fmt.Errorf("Tanggal to tidak boleh sebelum from")
+	}
+	end := to.AddDate(0, 0, 1)
+	if end.Sub(from) > reportMaxDays*24*time.Hour {
+		return reportRange{}, fmt.Errorf("Rentang laporan maksimal %d hari", reportMaxDays)
+	}
+	// Indonesia tidak memakai daylight saving sehingga satu offset berlaku untuk seluruh rentang
+	return reportRange{From: from, End: end, Timezone: from.Format("-07:00")}, nil
+}
+
+// SalesTotals adalah angka penjualan untuk satu periode atau seluruh rentang laporan.
+// Transaksi belum memiliki diskon maupun pajak, sehingga discounts dan tax bernilai 0 dan netSales sama dengan
+// grossSales sampai field discount dan tax tersedia pada transaksi.
+type SalesTotals struct {
+	Period      string  `json:"period,omitempty" bson:"_id"`
+	Orders      int     `json:"orders" bson:"orders"`
+	GrossSales  float64 `json:"grossSales" bson:"grossSales"`   // Harga layanan + ongkos antar-jemput + biaya penyimpanan, sebelum diskon
+	Discounts   float64 `json:"discounts" bson:"discounts"`     // Total potongan harga
+	Tax         float64 `json:"tax" bson:"tax"`                 // Pajak yang ditagihkan, tidak termasuk dalam netSales
+	NetSales    float64 `json:"netSales" bson:"netSales"`       // grossSales - discounts
+	KgProcessed float64 `json:"kgProcessed" bson:"kgProcessed"` // Jumlah item layanan kiloan
+	Collected   float64 `json:"collected" bson:"-"`             // Pembayaran bersih yang diterima (settle) pada periode ini
+}
+
+// ServiceSales adalah penjualan per layanan
+type ServiceSales struct {
+	ServiceID   primitive.ObjectID `json:"serviceId" bson:"_id"`
+	ServiceName string             `json:"serviceName" bson:"serviceName"`
+	Unit        string             `json:"unit" bson:"unit"`
+	Quantity    float64            `json:"quantity" bson:"quantity"`
+	Orders      int                `json:"orders" bson:"orders"` // Jumlah transaksi yang memuat layanan ini
+	Sales       float64            `json:"sales" bson:"sales"`
+}
+
+// PaymentMethodSales adalah pembayaran yang diterima per saluran dan metode
+type PaymentMethodSales struct {
+	Channel       string  `json:"channel" bson:"channel"`
+	PaymentMethod string  `json:"paymentMethod,omitempty" bson:"paymentMethod"`
+	Count         int     `json:"count" bson:"count"`
+	Amount        float64 `json:"amount" bson:"amount"`
+}
+
+// CashierSales adalah order yang diterima dan pembayaran manual yang dicatat oleh seorang kasir
+type CashierSales struct {
+	CashierID   primitive.ObjectID `json:"cashierId" bson:"_id"`
+	CashierName string             `json:"cashierName" bson:"cashierName"`
+	Orders      int                `json:"orders" bson:"orders"`
+	GrossSales  float64            `json:"grossSales" bson:"grossSales"`
+	Collected   float64            `json:"collected" bson:"collected"`
+}
+
+// SalesReport adalah hasil laporan penjualan
+type SalesReport struct {
+	From            string               `json:"from"`
+	To              string               `json:"to"`
+	Group           string               `json:"group"`
+	Summary         SalesTotals          `json:"summary"`
+	Periods         []SalesTotals        `json:"periods"`
+	ByService       []ServiceSales       `json:"byService"`
+	ByPaymentMethod []PaymentMethodSales `json:"byPaymentMethod"`
+	ByCashier       []CashierSales       `json:"byCashier"`
+}
+
+// salesTotalsGroup menjumlahkan kolom yang disiapkan oleh tahap $addFields laporan penjualan
+func salesTotalsGroup(id interface{}) bson.M {
+	return bson.M{
+		"_id":         id,
+		"orders":      bson.M{"$sum": 1},
+		"grossSales":  bson.M{"$sum": "$report.gross"},
+		"discounts":   bson.M{"$sum": "$report.discount"},
+		"tax":         bson.M{"$sum": "$report.tax"},
+		"netSales":    bson.M{"$sum": bson.M{"$subtract": bson.A{"$report.gross", "$report.discount"}}},
+		"kgProcessed": bson.M{"$sum": "$report.kg"},
+	}
+}
+
+// buildSalesReport menghitung laporan penjualan dari transaksi (berdasarkan tanggal order)
+// dan pembayaran yang diterima (berdasarkan tanggal settle)
+func buildSalesReport(ctx context.Context, rng reportRange, group string) (SalesReport, error) {
+	format := reportGroupFormats[group]
+	report := SalesReport{Group: group}
+
+	transactionPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"transactionDate": bson.M{"$gte": rng.From, "$lt": rng.End}}}},
+		{{Key: "$addFields", Value: bson.M{"report": bson.M{
+			"period": bson.M{"$dateToString": bson.M{"format": format, "date": "$transactionDate", "timezone": rng.Timezone}},
+			// Transaksi lama belum memiliki subtotal, total tagihannya berisi harga item saja
+			"gross": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{bson.M{"$type": "$subtotal"}, "missing"}},
+				bson.M{"$ifNull": bson.A{"$totalAmount", 0}},
+				bson.M{"$add": bson.A{
+					"$subtotal",
+					bson.M{"$ifNull": bson.A{"$deliveryFee", 0}},
+					bson.M{"$ifNull": bson.A{"$storageFee", 0}},
+				}},
+			}},
+			"discount": bson.M{"$ifNull": bson.A{"$discount", 0}},
+			"tax":      bson.M{"$ifNull": bson.A{"$tax", 0}},
+			"kg": bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$items", bson.A{}}},
+					"as":    "item",
+					"cond":  bson.M{"$eq": bson.A{bson.M{"$toLower": "$$item.service.unit"}, "kg"}},
+				}},
+				"as": "item",
+				"in": "$$item.quantity",
+			}}},
+		}}}},
+		{{Key: "$facet", Value: bson.M{
+			"summary": bson.A{bson.M{"$group": salesTotalsGroup(nil)}},
+			"periods": bson.A{
+				bson.M{"$group": salesTotalsGroup("$report.period")},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"byService": bson.A{
+				bson.M{"$unwind": "$items"},
+				bson.M{"$group": bson.M{
+					"_id":         "$items.serviceId",
+					"serviceName": bson.M{"$first": "$items.service.serviceName"},
+					"unit":        bson.M{"$first": "$items.service.unit"},
+					"quantity":    bson.M{"$sum": "$items.quantity"},
+					"orders":      bson.M{"$addToSet": "$_id"},
+					"sales":       bson.M{"$sum": "$items.totalPrice"},
+				}},
+				// Satu transaksi bisa memuat beberapa baris layanan yang sama, hitung transaksinya sekali
+				bson.M{"$addFields": bson.M{"orders": bson.M{"$size": "$orders"}}},
+				bson.M{"$sort": bson.M{"sales": -1}},
+			},
+			"byCashier": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":        "$cashierId",
+					"orders":     bson.M{"$sum": 1},
+					"grossSales": bson.M{"$sum": "$report.gross"},
+				}},
+			},
+		}}},
+	}
+
+	var transactionFacets []struct {
+		Summary   []SalesTotals  `bson:"summary"`
+		Periods   []SalesTotals  `bson:"periods"`
+		ByService []ServiceSales `bson:"byService"`
+		ByCashier []CashierSales `bson:"byCashier"`
+	}
+	if err := aggregateAll(ctx, config.TransactionCollection, transactionPipeline, &transactionFacets); err != nil {
+		return report, err
+	}
+
+	paymentFilter := paidBetween(rng.From, rng.End)
+	paymentFilter["status"] = bson.M{"$in": collectedPaymentStatuses}
+	paymentPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: paymentFilter}},
+		{{Key: "$addFields", Value: bson.M{"report": bson.M{
+			"period": bson.M{"$dateToString": bson.M{"format": format, "date": paidAtExpression, "timezone": rng.Timezone}},
+			"net":    netPaymentAmount,
+		}}}},
+		{{Key: "$facet", Value: bson.M{
+			"periods": bson.A{
+				bson.M{"$group": bson.M{"_id": "$report.period", "amount": bson.M{"$sum": "$report.net"}}},
+			},
+			"byMethod": bson.A{
+				bson.M{"$group": bson.M{
+					// Pembayaran lama tanpa channel dibuat lewat Midtrans
+					"_id":    bson.M{"channel": bson.M{"$ifNull": bson.A{"$channel", models.PaymentChannelMidtrans}}, "paymentMethod": "$payment_method"},
+					"count":  bson.M{"$sum": 1},
+					"amount": bson.M{"$sum": "$report.net"},
+				}},
+				bson.M{"$project": bson.M{"_id": 0, "channel": "$_id.channel", "paymentMethod": "$_id.paymentMethod", "count": 1, "amount": 1}},
+				bson.M{"$sort": bson.M{"amount": -1}},
+			},
+			"byCashier": bson.A{
+				bson.M{"$match": bson.M{"cashier_id": bson.M{"$exists": true}}},
+				bson.M{"$group": bson.M{"_id": "$cashier_id", "amount": bson.M{"$sum": "$report.net"}}},
+			},
+		}}},
+	}
+
+	type periodAmount struct {
+		ID     interface{} `bson:"_id"`
+		Amount float64     `bson:"amount"`
+	}
+	var paymentFacets []struct {
+		Periods   []periodAmount       `bson:"periods"`
+		ByMethod  []PaymentMethodSales `bson:"byMethod"`
+		ByCashier []periodAmount       `bson:"byCashier"`
+	}
+	if err := aggregateAll(ctx, config.PaymentCollection, paymentPipeline, &paymentFacets); err != nil {
+		return report, err
+	}
+
+	transactions, payments := transactionFacets[0], paymentFacets[0]
+	if len(transactions.Summary) > 0 {
+		report.Summary = transactions.Summary[0]
+		report.Summary.Period = ""
+	}
+	report.ByService = append([]ServiceSales{}, transactions.ByService...)
+	report.ByPaymentMethod = append([]PaymentMethodSales{}, payments.ByMethod...)
+
+	// Gabungkan pembayaran ke periode penjualan, periode yang hanya berisi pelunasan tetap ditampilkan
+	periods := map[string]*SalesTotals{}
+	for i := range transactions.Periods {
+		periods[transactions.Periods[i].Period] = &transactions.Periods[i]
+	}
+	for _, paid := range payments.Periods {
+		period, _ := paid.ID.(string)
+		if periods[period] == nil {
+			periods[period] = &SalesTotals{Period: period}
+		}
+		periods[period].Collected += paid.Amount
+		report.Summary.Collected += paid.Amount
+	}
+	report.Periods = []SalesTotals{}
+	for _, totals := range periods {
+		report.Periods = append(report.Periods, *totals)
+	}
+	sort.Slice(report.Periods, func(i, j int) bool { return report.Periods[i].Period < report.Periods[j].Period })
+
+	// Gabungkan pembayaran manual per kasir lalu lengkapi nama kasir
+	cashiers := map[primitive.ObjectID]*CashierSales{}
+	for i := range transactions.ByCashier {
+		cashiers[transactions.ByCashier[i].CashierID] = &transactions.ByCashier[i]
+	}
+	for _, paid := range payments.ByCashier {
+		id, _ := paid.ID.(primitive.ObjectID)
+		if cashiers[id] == nil {
+			cashiers[id] = &CashierSales{CashierID: id}
+		}
+		cashiers[id].Collected += paid.Amount
+	}
+	names, err := usernames(ctx, cashiers)
+	if err != nil {
+		return report, err
+	}
+	report.ByCashier = []CashierSales{}
+	for id, sales := range cashiers {
+		sales.CashierName = names[id]
+		report.ByCashier = append(report.ByCashier, *sales)
+	}
+	sort.Slice(report.ByCashier, func(i, j int) bool { return report.ByCashier[i].GrossSales > report.ByCashier[j].GrossSales })
+	return report, nil
+}
+
+// aggregateAll menjalankan pipeline agregasi dan membaca seluruh hasilnya
+func aggregateAll(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline, out interface{}) error {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, out)
+}
+
+// usernames mengambil nama pengguna untuk sekumpulan ID kasir. Order lama tanpa kasir diberi nama "-".
+func usernames(ctx context.Context, cashiers map[primitive.ObjectID]*CashierSales) (map[primitive.ObjectID]string, error) {
+	ids := []primitive.ObjectID{}
+	for id := range cashiers {
+		ids = append(ids, id)
+	}
+	names := map[primitive.ObjectID]string{primitive.NilObjectID: "-"}
+
+	cursor, err := config.UserCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return names, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return names, err
+	}
+	for _, user := range users {
+		names[user.ID] = user.Username
+	}
+	return names, nil
+}
+
+// Fungsi untuk laporan penjualan: parameter from, to (YYYY-MM-DD) dan group (day, week, atau month)
+func GetSalesReport(w http.ResponseWriter, r *http.Request) {
+	group := r.URL.Query().Get("group")
+	if group == "" {
+		group = "day"
+	}
+	if _, ok := reportGroupFormats[group]; !ok {
+		http.Error(w, "group harus day, week, atau month", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rng, err := parseReportRange(r, utils.ShopLocation(loadShopSettings(ctx)), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := buildSalesReport(ctx, rng, group)
+	if err != nil {
+		http.Error(w, "Gagal membuat laporan penjualan", http.StatusInternalServerError)
+		return
+	}
+	report.From = rng.From.Format("2006-01-02")
+	report.To = rng.End.AddDate(0, 0, -1).Format("2006-01-02")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
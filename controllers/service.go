@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"laundry-pos/config"
 	"laundry-pos/models"
 	"net/http"
@@ -21,6 +22,11 @@ func CreateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateSpeedTiers(service.SpeedTiers); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Generate ObjectID untuk layanan baru
 	service.ID = primitive.NewObjectID()
 
@@ -128,6 +134,11 @@ func UpdateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateSpeedTiers(updatedService.SpeedTiers); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Set ID ke service sebelum update
 	updatedService.ID = id
 
@@ -137,10 +148,12 @@ func UpdateService(w http.ResponseWriter, r *http.Request) {
 
 	update := bson.M{
 		"$set": bson.M{
-			"serviceName": updatedService.ServiceName,
-			"description": updatedService.Description,
-			"unitPrice":   updatedService.UnitPrice,
-			"unit":        updatedService.Unit,
+			"serviceName":     updatedService.ServiceName,
+			"description":     updatedService.Description,
+			"unitPrice":       updatedService.UnitPrice,
+			"unit":            updatedService.Unit,
+			"turnaroundHours": updatedService.TurnaroundHours,
+			"speedTiers":      updatedService.SpeedTiers,
 		},
 	}
 
@@ -194,3 +207,43 @@ func DeleteService(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewEncoder(w).Encode(response)
 }
+
+// validateSpeedTiers memastikan tingkat kecepatan yang didaftarkan valid dan tidak ganda
+func validateSpeedTiers(tiers []models.SpeedTier) error {
+	seen := map[string]bool{}
+	for _, tier := range tiers {
+		switch tier.Name {
+		case models.SpeedRegular, models.SpeedExpress, models.SpeedKilat:
+		default:
+			return fmt.Errorf("Tingkat kecepatan '%s' tidak dikenal", tier.Name)
+		}
+		if seen[tier.Name] {
+			return fmt.Errorf("Tingkat kecepatan '%s' didaftarkan lebih dari sekali", tier.Name)
+		}
+		if tier.PriceMultiplier < 0 || tier.Surcharge < 0 || tier.TurnaroundHours < 0 {
+			return fmt.Errorf("Nilai tingkat kecepatan '%s' tidak boleh negatif", tier.Name)
+		}
+		seen[tier.Name] = true
+	}
+	return nil
+}
+
+// findSpeedTier mencari tingkat kecepatan layanan, "regular" tanpa konfigurasi memakai harga dasar
+func findSpeedTier(service models.Service, name string) (models.SpeedTier, bool) {
+	if name == "" {
+		name = models.SpeedRegular
+	}
+	for _, tier := range service.SpeedTiers {
+		if tier.Name == name {
+			return tier, true
+		}
+	}
+	if name == models.SpeedRegular {
+		return models.SpeedTier{
+			Name:            models.SpeedRegular,
+			PriceMultiplier: 1,
+			TurnaroundHours: service.TurnaroundHours,
+		}, true
+	}
+	return models.SpeedTier{}, false
+}
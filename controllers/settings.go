@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/services"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Pengaturan bawaan jika toko belum menyimpan pengaturan apa pun
+var defaultShopSettings = models.ShopSettings{
+	ShopName:          "Laundry POS",
+	InvoicePrefix:     "LDR",
+	Timezone:          "Asia/Jakarta",
+	OpenTime:          "08:00",
+	CloseTime:         "20:00",
+	ClosedDays:        []int{},
+	Holidays:          []string{},
+	PickupSlotMinutes: 60,
+	DeliveryZones:     []models.DeliveryZone{},
+
+	UncollectedReminderDays: 3,
+	UnpaidReminderDays:      7,
+	StorageFeeAfterDays:     30,
+	PaymentProvider:         services.ProviderMidtrans,
+}
+
+// Prefix nomor order yang diizinkan, misalnya "LDR"
+var invoicePrefixPattern = regexp.MustCompile(`^[A-Z0-9]{1,8}$`)
+
+// loadShopSettings mengambil pengaturan toko dan mengisi nilai kosong dengan bawaan
+func loadShopSettings(ctx context.Context) models.ShopSettings {
+	settings := defaultShopSettings
+	var stored models.ShopSettings
+	if err := config.SettingsCollection.FindOne(ctx, bson.M{}).Decode(&stored); err != nil {
+		return settings
+	}
+	return mergeShopSettings(stored)
+}
+
+// mergeShopSettings mengisi field pengaturan yang kosong dengan nilai bawaan
+func mergeShopSettings(settings models.ShopSettings) models.ShopSettings {
+	if settings.ShopName == "" {
+		settings.ShopName = defaultShopSettings.ShopName
+	}
+	if settings.InvoicePrefix == "" {
+		settings.InvoicePrefix = defaultShopSettings.InvoicePrefix
+	}
+	if settings.Timezone == "" {
+		settings.Timezone = defaultShopSettings.Timezone
+	}
+	if settings.OpenTime == "" {
+		settings.OpenTime = defaultShopSettings.OpenTime
+	}
+	if settings.CloseTime == "" {
+		settings.CloseTime = defaultShopSettings.CloseTime
+	}
+	if settings.PickupSlotMinutes <= 0 {
+		settings.PickupSlotMinutes = defaultShopSettings.PickupSlotMinutes
+	}
+	if settings.DeliveryZones == nil {
+		settings.DeliveryZones = defaultShopSettings.DeliveryZones
+	}
+	if settings.UncollectedReminderDays <= 0 {
+		settings.UncollectedReminderDays = defaultShopSettings.UncollectedReminderDays
+	}
+	if settings.UnpaidReminderDays <= 0 {
+		settings.UnpaidReminderDays = defaultShopSettings.UnpaidReminderDays
+	}
+	if settings.StorageFeeAfterDays <= 0 {
+		settings.StorageFeeAfterDays = defaultShopSettings.StorageFeeAfterDays
+	}
+	if settings.PaymentProvider == "" {
+		settings.PaymentProvider = defaultShopSettings.PaymentProvider
+	}
+	return settings
+}
+
+// Fungsi untuk mendapatkan pengaturan toko
+func GetSettings(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loadShopSettings(ctx))
+}
+
+// Fungsi untuk memperbarui pengaturan toko
+func UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var settings models.ShopSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	// Nomor order dicari tanpa membedakan huruf besar/kecil, jadi prefix selalu disimpan dalam huruf besar.
+	// Prefix juga menjadi bagian order_id payment gateway, hanya huruf dan angka yang diizinkan.
+	settings.InvoicePrefix = strings.ToUpper(strings.TrimSpace(settings.InvoicePrefix))
+	if settings.InvoicePrefix != "" && !invoicePrefixPattern.MatchString(settings.InvoicePrefix) {
+		http.Error(w, "Prefix invoice harus 1-8 huruf atau angka", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := time.Parse("15:04", settings.OpenTime); settings.OpenTime != "" && err != nil {
+		http.Error(w, "Format jam buka harus HH:MM", http.StatusBadRequest)
+		return
+	}
+	if _, err := time.Parse("15:04", settings.CloseTime); settings.CloseTime != "" && err != nil {
+		http.Error(w, "Format jam tutup harus HH:MM", http.StatusBadRequest)
+		return
+	}
+	for _, day := range settings.ClosedDays {
+		if day < 0 || day > 6 {
+			http.Error(w, "Hari tutup harus bernilai 0 (Minggu) sampai 6 (Sabtu)", http.StatusBadRequest)
+			return
+		}
+	}
+	for _, holiday := range settings.Holidays {
+		if _, err := time.Parse("2006-01-02", holiday); err != nil {
+			http.Error(w, "Format tanggal libur harus YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	for _, zone := range settings.DeliveryZones {
+		if zone.Name == "" || zone.MaxDistanceKm <= 0 || zone.Fee < 0 {
+			http.Error(w, "Zona antar-jemput harus memiliki nama, jarak maksimal, dan ongkos yang valid", http.StatusBadRequest)
+			return
+		}
+	}
+	if settings.StorageFeePerDay < 0 {
+		http.Error(w, "Biaya penyimpanan tidak boleh negatif", http.StatusBadRequest)
+		return
+	}
+	if settings.PaymentProvider != "" && settings.PaymentProvider != services.ProviderMidtrans && settings.PaymentProvider != services.ProviderXendit {
+		http.Error(w, "Payment gateway harus midtrans atau xendit", http.StatusBadRequest)
+		return
+	}
+	if _, err := time.LoadLocation(settings.Timezone); settings.Timezone != "" && err != nil {
+		http.Error(w, "Zona waktu tidak dikenal", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Hanya ada satu dokumen pengaturan, buat jika belum ada
+	settings.ID = primitive.NilObjectID
+	_, err := config.SettingsCollection.ReplaceOne(ctx, bson.M{}, settings, options.Replace().SetUpsert(true))
+	if err != nil {
+		http.Error(w, "Gagal menyimpan pengaturan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"message":  "Pengaturan berhasil disimpan",
+		"settings": mergeShopSettings(settings),
+	}
+	json.NewEncoder(w).Encode(response)
+}
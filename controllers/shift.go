@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/utils"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fungsi untuk membuka shift kasir dengan uang modal awal
+func OpenShift(w http.ResponseWriter, r *http.Request) {
+	claims, _ := utils.ClaimsFromContext(r.Context())
+	cashierID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Pengguna tidak dikenali", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		OpeningFloat float64 `json:"openingFloat"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if req.OpeningFloat < 0 {
+		http.Error(w, "Modal awal tidak boleh negatif", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := findOpenShift(ctx, cashierID); err == nil {
+		http.Error(w, "Masih ada shift yang terbuka, tutup shift tersebut terlebih dahulu", http.StatusConflict)
+		return
+	}
+
+	shift := models.Shift{
+		CashierID:    cashierID,
+		CashierName:  claims.Username,
+		Status:       models.ShiftOpen,
+		OpeningFloat: req.OpeningFloat,
+		OpenedAt:     time.Now(),
+	}
+	result, err := config.ShiftCollection.InsertOne(ctx, shift)
+	if mongo.IsDuplicateKeyError(err) {
+		http.Error(w, "Masih ada shift yang terbuka, tutup shift tersebut terlebih dahulu", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Gagal membuka shift", http.StatusInternalServerError)
+		return
+	}
+	shift.ID = result.InsertedID.(primitive.ObjectID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shift)
+}
+
+// Fungsi untuk melihat shift yang sedang terbuka beserta rekap kas sementara
+func GetCurrentShift(w http.ResponseWriter, r *http.Request) {
+	cashierID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Pengguna tidak dikenali", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	shift, err := findOpenShift(ctx, cashierID)
+	if err != nil {
+		http.Error(w, "Tidak ada shift yang terbuka", http.StatusNotFound)
+		return
+	}
+	if err := summarizeShift(ctx, &shift); err != nil {
+		http.Error(w, "Gagal menghitung rekap shift", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+// Fungsi untuk mencatat uang masuk (pay-in) atau keluar (pay-out) dari laci, misalnya beli deterjen
+func RecordCashMovement(w http.ResponseWriter, r *http.Request) {
+	cashierID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Pengguna tidak dikenali", http.StatusUnauthorized)
+		return
+	}
+
+	var movement models.CashMovement
+	if err := json.NewDecoder(r.Body).Decode(&movement); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if movement.Kind != models.CashPayIn && movement.Kind != models.CashPayOut {
+		http.Error(w, "Jenis harus pay_in atau pay_out", http.StatusBadRequest)
+		return
+	}
+	movement.Amount = math.Round(movement.Amount)
+	if movement.Amount <= 0 {
+		http.Error(w, "Jumlah harus lebih dari 0", http.StatusBadRequest)
+		return
+	}
+	movement.Reason = strings.TrimSpace(movement.Reason)
+	if movement.Reason == "" {
+		http.Error(w, "Keterangan wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	shift, err := findOpenShift(ctx, cashierID)
+	if err != nil {
+		http.Error(w, "Buka shift terlebih dahulu", http.StatusBadRequest)
+		return
+	}
+
+	movement.ID = primitive.NilObjectID
+	movement.ShiftID = shift.ID
+	movement.CreatedBy = cashierID
+	movement.CreatedAt = time.Now()
+	result, err := config.CashMovementCollection.InsertOne(ctx, movement)
+	if err != nil {
+		http.Error(w, "Gagal mencatat kas", http.StatusInternalServerError)
+		return
+	}
+	movement.ID = result.InsertedID.(primitive.ObjectID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+// Fungsi untuk menutup shift dengan jumlah uang yang dihitung dan menghasilkan selisih lebih/kurang
+func CloseShift(w http.ResponseWriter, r *http.Request) {
+	cashierID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Pengguna tidak dikenali", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		CountedCash *float64 `json:"countedCash"`
+		Note        string   `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if req.CountedCash == nil || *req.CountedCash < 0 {
+		http.Error(w, "Jumlah uang yang dihitung wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	shift, err := findOpenShift(ctx, cashierID)
+	if err != nil {
+		http.Error(w, "Tidak ada shift yang terbuka", http.StatusNotFound)
+		return
+	}
+	if err := summarizeShift(ctx, &shift); err != nil {
+		http.Error(w, "Gagal menghitung rekap shift", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	shift.Status = models.ShiftClosed
+	shift.ClosedAt = &now
+	shift.CountedCash = *req.CountedCash
+	shift.Variance = shift.CountedCash - shift.ExpectedCash
+	shift.Note = req.Note
+
+	// Hanya shift yang masih terbuka yang ditutup, mencegah tutup ganda
+	result, err := config.ShiftCollection.ReplaceOne(ctx, bson.M{"_id": shift.ID, "status": models.ShiftOpen}, shift)
+	if err != nil {
+		http.Error(w, "Gagal menutup shift", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "Shift sudah ditutup", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+// Fungsi untuk laporan shift (lebih/kurang per kasir), filter ?cashierId=, ?from= dan ?to= (YYYY-MM-DD)
+func GetShifts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := bson.M{}
+
+	// Selain admin hanya boleh melihat shift miliknya sendiri
+	claims, _ := utils.ClaimsFromContext(r.Context())
+	if claims.Role != models.RoleAdmin {
+		cashierID, _ := currentUserID(r)
+		filter["cashierId"] = cashierID
+	} else if id := query.Get("cashierId"); id != "" {
+		cashierID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			http.Error(w, "ID kasir tidak valid", http.StatusBadRequest)
+			return
+		}
+		filter["cashierId"] = cashierID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	loc := utils.ShopLocation(loadShopSettings(ctx))
+	openedAt := bson.M{}
+	if from := query.Get("from"); from != "" {
+		day, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			http.Error(w, "Format tanggal from harus YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		openedAt["$gte"] = day
+	}
+	if to := query.Get("to"); to != "" {
+		day, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			http.Error(w, "Format tanggal to harus YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		openedAt["$lt"] = day.AddDate(0, 0, 1)
+	}
+	if len(openedAt) > 0 {
+		filter["openedAt"] = openedAt
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "openedAt", Value: -1}})
+	cursor, err := config.ShiftCollection.Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, "Gagal mengambil data shift", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	shifts := []models.Shift{}
+	if err := cursor.All(ctx, &shifts); err != nil {
+		http.Error(w, "Gagal memproses data shift", http.StatusInternalServerError)
+		return
+	}
+
+	// Rekap selisih per kasir untuk shift yang sudah ditutup
+	type cashierSummary struct {
+		CashierID   primitive.ObjectID `json:"cashierId"`
+		CashierName string             `json:"cashierName"`
+		Shifts      int                `json:"shifts"`
+		Over        float64            `json:"over"`
+		Short       float64            `json:"short"`
+		NetVariance float64            `json:"netVariance"`
+	}
+	summaries := map[primitive.ObjectID]*cashierSummary{}
+	order := []primitive.ObjectID{}
+	for i := range shifts {
+		if shifts[i].Status == models.ShiftOpen {
+			if err := summarizeShift(ctx, &shifts[i]); err != nil {
+				http.Error(w, "Gagal menghitung rekap shift", http.StatusInternalServerError)
+				return
+			}
+			continue
+		}
+
+		summary, ok := summaries[shifts[i].CashierID]
+		if !ok {
+			summary = &cashierSummary{CashierID: shifts[i].CashierID, CashierName: shifts[i].CashierName}
+			summaries[shifts[i].CashierID] = summary
+			order = append(order, shifts[i].CashierID)
+		}
+		summary.Shifts++
+		summary.NetVariance += shifts[i].Variance
+		if shifts[i].Variance > 0 {
+			summary.Over += shifts[i].Variance
+		} else {
+			summary.Short -= shifts[i].Variance
+		}
+	}
+
+	byCashier := []cashierSummary{}
+	for _, id := range order {
+		byCashier = append(byCashier, *summaries[id])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"shifts":    shifts,
+		"byCashier": byCashier,
+	})
+}
+
+// findOpenShift mencari shift yang masih terbuka milik kasir
+func findOpenShift(ctx context.Context, cashierID primitive.ObjectID) (models.Shift, error) {
+	var shift models.Shift
+	err := config.ShiftCollection.FindOne(ctx, bson.M{"cashierId": cashierID, "status": models.ShiftOpen}).Decode(&shift)
+	return shift, err
+}
+
+// openShiftID mengembalikan ID shift terbuka milik user, NilObjectID jika tidak ada
+func openShiftID(ctx context.Context, userID primitive.ObjectID) primitive.ObjectID {
+	shift, err := findOpenShift(ctx, userID)
+	if err != nil {
+		return primitive.NilObjectID
+	}
+	return shift.ID
+}
+
+// summarizeShift menghitung penjualan tunai, refund tunai, pay-in/pay-out, dan kas yang seharusnya ada di laci
+func summarizeShift(ctx context.Context, shift *models.Shift) error {
+	cashSales, err := sumField(ctx, config.PaymentCollection, bson.M{
+		"shift_id": shift.ID,
+		"channel":  models.PaymentChannelCash,
+		"status":   bson.M{"$in": collectedPaymentStatuses},
+	}, "$gross_amount")
+	if err != nil {
+		return err
+	}
+
+	cashRefunds, err := sumField(ctx, config.RefundCollection, bson.M{
+		"shiftId": shift.ID,
+		"method":  models.RefundMethodCashOut,
+		"status":  models.RefundCompleted,
+	}, "$amount")
+	if err != nil {
+		return err
+	}
+
+	payIns, err := sumField(ctx, config.CashMovementCollection, bson.M{"shiftId": shift.ID, "kind": models.CashPayIn}, "$amount")
+	if err != nil {
+		return err
+	}
+	payOuts, err := sumField(ctx, config.CashMovementCollection, bson.M{"shiftId": shift.ID, "kind": models.CashPayOut}, "$amount")
+	if err != nil {
+		return err
+	}
+
+	shift.CashSales = cashSales
+	shift.CashRefunds = cashRefunds
+	shift.PayIns = payIns
+	shift.PayOuts = payOuts
+	shift.ExpectedCash = shift.OpeningFloat + cashSales - cashRefunds + payIns - payOuts
+	return nil
+}
+
+// sumField menjumlahkan sebuah field dari dokumen yang cocok dengan filter
+func sumField(ctx context.Context, collection *mongo.Collection, filter bson.M, field interface{}) (float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": field}}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("gagal menjumlahkan %v: %w", field, err)
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return result[0].Total, nil
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"laundry-pos/utils"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Batas percobaan lacak order yang gagal per jendela waktu agar kode verifikasi 6 karakter tidak bisa ditebak.
+// Batas per nomor order melindungi satu order dari tebakan yang tersebar di banyak IP.
+const (
+	trackingWindow      = 15 * time.Minute
+	trackingMaxPerIP    = 20
+	trackingMaxPerOrder = 5
+)
+
+// trackingLimit adalah satu hitungan percobaan gagal beserta batasnya
+type trackingLimit struct {
+	Key   string
+	Limit int
+}
+
+// Data yang boleh dilihat customer tanpa login, tanpa data pribadi customer
+type trackingResponse struct {
+	OrderNumber         string           `json:"orderNumber"`
+	Status              string           `json:"status"`
+	TransactionDate     time.Time        `json:"transactionDate"`
+	EstimatedCompletion time.Time        `json:"estimatedCompletion"`
+	PickupSlot          *models.TimeSlot `json:"pickupSlot,omitempty"`
+	ReadyAt             *time.Time       `json:"readyAt,omitempty"`
+	TotalAmount         float64          `json:"totalAmount"`
+	AmountDue           float64          `json:"amountDue"`
+}
+
+// Fungsi publik untuk melacak status order dengan nomor order dan kode verifikasi (atau token link)
+// Query: order=LDR-20261018-0042&code=ABC123 atau order=...&token=...
+func TrackOrder(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	orderNumber := strings.TrimSpace(query.Get("order"))
+	code := strings.ToUpper(strings.TrimSpace(query.Get("code")))
+	token := strings.TrimSpace(query.Get("token"))
+	if orderNumber == "" || (code == "" && token == "") {
+		http.Error(w, "Nomor order dan kode verifikasi wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Token link pelacakan tidak bisa ditebak, sehingga hanya batas per IP yang berlaku untuknya.
+	// Dengan begitu tebakan kode dari orang lain tidak mengunci customer yang membuka link dari notifikasi.
+	now := time.Now()
+	limits := trackingLimits(r, orderNumber, token != "", now)
+	retryAfter, err := trackingThrottled(ctx, limits, now)
+	if err != nil {
+		http.Error(w, "Gagal memeriksa order", http.StatusInternalServerError)
+		return
+	}
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		http.Error(w, "Terlalu banyak percobaan, coba lagi nanti", http.StatusTooManyRequests)
+		return
+	}
+
+	// Pesan yang sama untuk order tidak ada maupun kode salah agar nomor order tidak bisa ditebak
+	var transaction models.Transaction
+	// Prefix invoice disimpan dalam huruf besar, order lama bisa memakai prefix huruf kecil sesuai yang tercetak
+	filter := bson.M{"orderNumber": bson.M{"$in": bson.A{orderNumber, strings.ToUpper(orderNumber)}}}
+	err = config.TransactionCollection.FindOne(ctx, filter).Decode(&transaction)
+	if err != nil || !verifyTracking(transaction, code, token) {
+		recordTrackingFailure(ctx, limits, now)
+		http.Error(w, "Order tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	// Endpoint publik hanya membaca, amountPaid sudah diperbarui setiap kali pembayaran, refund, atau webhook tersimpan
+	amountDue := outstandingBalance(transaction)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trackingResponse{
+		OrderNumber:         transaction.OrderNumber,
+		Status:              transaction.Status,
+		TransactionDate:     transaction.TransactionDate,
+		EstimatedCompletion: transaction.EstimatedCompletion,
+		PickupSlot:          transaction.PickupSlot,
+		ReadyAt:             transaction.ReadyAt,
+		TotalAmount:         transaction.TotalAmount,
+		AmountDue:           amountDue,
+	})
+}
+
+// trackingLimits menyusun kunci hitungan percobaan gagal per IP dan per nomor order untuk jendela waktu saat ini
+func trackingLimits(r *http.Request, orderNumber string, withToken bool, now time.Time) []trackingLimit {
+	window := strconv.FormatInt(now.Truncate(trackingWindow).Unix(), 10)
+	limits := []trackingLimit{{Key: "ip:" + clientIP(r) + ":" + window, Limit: trackingMaxPerIP}}
+	if !withToken {
+		limits = append(limits, trackingLimit{Key: "order:" + strings.ToUpper(orderNumber) + ":" + window, Limit: trackingMaxPerOrder})
+	}
+	return limits
+}
+
+// trackingThrottled mengembalikan sisa waktu tunggu jika salah satu hitungan sudah mencapai batas
+func trackingThrottled(ctx context.Context, limits []trackingLimit, now time.Time) (time.Duration, error) {
+	keys := bson.A{}
+	for _, limit := range limits {
+		keys = append(keys, limit.Key)
+	}
+	cursor, err := config.TrackingAttemptCollection.Find(ctx, bson.M{"_id": bson.M{"$in": keys}})
+	if err != nil {
+		return 0, err
+	}
+	var attempts []struct {
+		Key      string `bson:"_id"`
+		Failures int    `bson:"failures"`
+	}
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return 0, err
+	}
+
+	for _, attempt := range attempts {
+		for _, limit := range limits {
+			if attempt.Key == limit.Key && attempt.Failures >= limit.Limit {
+				return now.Truncate(trackingWindow).Add(trackingWindow).Sub(now), nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// recordTrackingFailure menambah hitungan percobaan gagal, dokumen dihapus otomatis setelah jendelanya lewat
+func recordTrackingFailure(ctx context.Context, limits []trackingLimit, now time.Time) {
+	expiresAt := now.Truncate(trackingWindow).Add(trackingWindow)
+	opts := options.Update().SetUpsert(true)
+	for _, limit := range limits {
+		update := bson.M{"$inc": bson.M{"failures": 1}, "$setOnInsert": bson.M{"expiresAt": expiresAt}}
+		if _, err := config.TrackingAttemptCollection.UpdateOne(ctx, bson.M{"_id": limit.Key}, update, opts); err != nil {
+			log.Printf("Gagal mencatat percobaan lacak order: %v", err)
+			return
+		}
+	}
+}
+
+// clientIP mengambil IP pengunjung. Di Vercel alamat asli ada di X-Forwarded-For yang diisi oleh proxy Vercel.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// verifyTracking mencocokkan kode verifikasi atau token link pelacakan
+func verifyTracking(transaction models.Transaction, code, token string) bool {
+	if token != "" {
+		return utils.VerifyTrackingToken(transaction.OrderNumber, token)
+	}
+	if transaction.TrackingCode == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(transaction.TrackingCode), []byte(code)) == 1
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		forwarded  string
+		remoteAddr string
+		want       string
+	}{
+		{"", "10.0.0.5:51234", "10.0.0.5"},
+		{"203.0.113.7", "10.0.0.5:51234", "203.0.113.7"},
+		{"203.0.113.7, 10.0.0.1", "10.0.0.5:51234", "203.0.113.7"},
+		{"", "10.0.0.5", "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/track", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if got := clientIP(r); got != tt.want {
+			t.Errorf("clientIP(%q, %q) = %q, want %q", tt.forwarded, tt.remoteAddr, got, tt.want)
+		}
+	}
+}
+
+func TestTrackingLimits(t *testing.T) {
+	r := httptest.NewRequest("GET", "/track", nil)
+	r.RemoteAddr = "10.0.0.5:51234"
+	now := time.Date(2026, 10, 19, 10, 7, 0, 0, time.UTC)
+
+	limits := trackingLimits(r, "ldr-20261019-0001", false, now)
+	if len(limits) != 2 {
+		t.Fatalf("lacak dengan kode seharusnya dibatasi per IP dan per order, got %+v", limits)
+	}
+	if !strings.HasPrefix(limits[1].Key, "order:LDR-20261019-0001:") {
+		t.Errorf("nomor order tidak dinormalisasi: %q", limits[1].Key)
+	}
+	// Percobaan dalam jendela yang sama memakai kunci yang sama
+	later := trackingLimits(r, "LDR-20261019-0001", false, now.Add(5*time.Minute))
+	if later[0].Key != limits[0].Key || later[1].Key != limits[1].Key {
+		t.Errorf("kunci berubah dalam satu jendela: %+v vs %+v", limits, later)
+	}
+
+	// Token link tidak bisa ditebak, hanya batas per IP yang berlaku
+	if withToken := trackingLimits(r, "LDR-20261019-0001", true, now); len(withToken) != 1 {
+		t.Errorf("lacak dengan token seharusnya hanya dibatasi per IP, got %+v", withToken)
+	}
+}
@@ -3,13 +3,20 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"laundry-pos/config"
 	"laundry-pos/models"
+	"laundry-pos/services"
+	"laundry-pos/utils"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Fungsi untuk membuat transaksi baru
@@ -30,8 +37,9 @@ func CreateTransaction(w http.ResponseWriter, r *http.Request) {
 
 	transaction.ID = primitive.NewObjectID()
 	transaction.TransactionDate = time.Now()
-	transaction.Status = "Pending"
+	transaction.Status = models.TransactionPending
 	transaction.Customer = customer
+	transaction.CashierID, _ = currentUserID(r)
 
 
 	var totalAmount float64
@@ -45,25 +53,85 @@ func CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		}
 
 		transaction.Items[i].ID = primitive.NewObjectID()
-		transaction.Items[i].UnitPrice = service.UnitPrice
-		transaction.Items[i].Service = service
-		transaction.Items[i].TotalPrice = float64(item.Quantity) * service.UnitPrice
+		if err := applyServiceToItem(&transaction.Items[i], service); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		totalAmount += transaction.Items[i].TotalPrice
 	}
 
-	transaction.TotalAmount = totalAmount
-
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, err = config.TransactionCollection.InsertOne(ctx, transaction)
+	originalItems := cloneItems(transaction.Items)
+	if err := assignTagCodes(ctx, transaction.ID, transaction.Items); err != nil {
+		http.Error(w, err.Error(), tagCodeErrorStatus(err))
+		return
+	}
+	transaction.TagCodes = tagCodesOf(transaction.Items)
+
+	settings := loadShopSettings(ctx)
+
+	// Validasi penjemputan/pengantaran dan hitung ongkosnya sesuai zona
+	for _, logistics := range []*models.Logistics{transaction.Pickup, transaction.Delivery} {
+		if err := prepareLogistics(ctx, logistics, settings); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	transaction.Subtotal = totalAmount
+	recalculateTotal(&transaction)
+
+	// Hitung perkiraan selesai berdasarkan jam kerja toko dan tentukan slot pengambilan
+	transaction.EstimatedCompletion = estimateCompletion(transaction.TransactionDate, transaction.Items, settings)
+	if err := assignPickupSlot(&transaction, settings); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transaction.TrackingCode = utils.RandomCode(6)
+	transaction.AmountPaid = 0
+	transaction.PaymentStatus = paymentStatusFor(transaction.TotalAmount, 0)
+
+	// Nomor order dibuat paling akhir agar nomor urut tidak terbuang oleh input yang tidak valid.
+	// Nomor order unik, jika penghitung pernah direset pakai nomor berikutnya.
+	transaction.OrderNumber, err = nextOrderNumber(ctx, settings, transaction.TransactionDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for attempt := 1; ; attempt++ {
+		_, err = config.TransactionCollection.InsertOne(ctx, transaction)
+		if !mongo.IsDuplicateKeyError(err) || attempt == 5 {
+			break
+		}
+		// Kode tag bentrok dengan transaksi yang disimpan bersamaan, selain itu nomor order yang bentrok
+		if isTagCodeConflict(err) {
+			if err := reassignTagCodes(ctx, transaction.ID, &transaction, originalItems); err != nil {
+				http.Error(w, err.Error(), tagCodeErrorStatus(err))
+				return
+			}
+			continue
+		}
+		transaction.OrderNumber, err = nextOrderNumber(ctx, settings, transaction.TransactionDate)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 	if err != nil {
 		http.Error(w, "Gagal menyimpan transaksi", http.StatusInternalServerError)
 		return
 	}
 
-	// Tambahkan data customer ke response
+	queueNotification(ctx, transaction, services.EventOrderReceived, services.NotificationData{})
+
+	// Tambahkan data customer dan token link pelacakan ke response
 	transaction.Customer = customer
+	if token, err := utils.SignTrackingToken(transaction.OrderNumber); err == nil {
+		transaction.TrackingToken = token
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(transaction)
@@ -74,7 +142,13 @@ func GetTransactions(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := config.TransactionCollection.Find(ctx, bson.M{})
+	// Pencarian berdasarkan nomor order, misalnya ?q=LDR-20261018 atau ?q=0042
+	filter := bson.M{}
+	if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		filter["orderNumber"] = bson.M{"$regex": regexp.QuoteMeta(q), "$options": "i"}
+	}
+
+	cursor, err := config.TransactionCollection.Find(ctx, filter)
 	if err != nil {
 		http.Error(w, "Gagal mendapatkan data transaksi", http.StatusInternalServerError)
 		return
@@ -130,6 +204,13 @@ func GetTransactionByID(w http.ResponseWriter, r *http.Request) {
 		transaction.Customer = customer
 	}
 
+	// Token link pelacakan untuk dibagikan staf ke customer, tanpa TRACKING_SECRET_KEY hanya kode verifikasi yang bisa dipakai
+	if transaction.OrderNumber != "" {
+		if token, err := utils.SignTrackingToken(transaction.OrderNumber); err == nil {
+			transaction.TrackingToken = token
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(transaction)
 }
@@ -165,35 +246,111 @@ func UpdateTransaction(w http.ResponseWriter, r *http.Request) {
         }
 		
 		transaction.Items[i].ID = item.ID  // Tetap gunakan ID yang ada
-        transaction.Items[i].UnitPrice = service.UnitPrice
-        transaction.Items[i].Service = service
-        transaction.Items[i].TotalPrice = float64(item.Quantity) * service.UnitPrice
+        if err := applyServiceToItem(&transaction.Items[i], service); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
         totalAmount += transaction.Items[i].TotalPrice
     }
 
-    transaction.TotalAmount = totalAmount
-    transaction.TransactionDate = time.Now()
-
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    // Update transaksi di database
-    update := bson.M{
-        "$set": transaction,
+    originalItems := cloneItems(transaction.Items)
+    if err := assignTagCodes(ctx, objID, transaction.Items); err != nil {
+        http.Error(w, err.Error(), tagCodeErrorStatus(err))
+        return
+    }
+    transaction.TagCodes = tagCodesOf(transaction.Items)
+
+    settings := loadShopSettings(ctx)
+    for _, logistics := range []*models.Logistics{transaction.Pickup, transaction.Delivery} {
+        if err := prepareLogistics(ctx, logistics, settings); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
     }
 
-    result, err := config.TransactionCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+    // Biaya penyimpanan dihitung oleh job pengingat, pertahankan nilai yang sudah ada.
+    // Antar-jemput yang tidak dikirim ulang juga tetap dipakai agar ongkosnya ikut terhitung.
+    // Tanggal transaksi tidak berubah saat diedit agar perkiraan selesai, laporan, dan umur piutang tetap sama.
+    var existing models.Transaction
+    if err := config.TransactionCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&existing); err != nil {
+        http.Error(w, "Transaksi tidak ditemukan untuk diperbarui", http.StatusNotFound)
+        return
+    }
+    transaction.TransactionDate = existing.TransactionDate
+    transaction.StorageFee = existing.StorageFee
+    if transaction.Pickup == nil {
+        transaction.Pickup = existing.Pickup
+    }
+    if transaction.Delivery == nil {
+        transaction.Delivery = existing.Delivery
+    }
+
+    transaction.Subtotal = totalAmount
+    recalculateTotal(&transaction)
+    transaction.EstimatedCompletion = estimateCompletion(transaction.TransactionDate, transaction.Items, settings)
+    if transaction.PickupSlot != nil {
+        if err := assignPickupSlot(&transaction, settings); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+    }
+
+    // Update transaksi di database. Hanya field yang boleh diedit yang ditulis; status, waktu siap/selesai,
+    // nomor order, dan data pelunasan hanya diubah lewat endpoint status dan pembayaran.
+    fields := bson.M{
+        "customerId":          transaction.CustomerID,
+        "items":               transaction.Items,
+        "subtotal":            transaction.Subtotal,
+        "deliveryFee":         transaction.DeliveryFee,
+        "totalAmount":         transaction.TotalAmount,
+        "paymentMethod":       transaction.PaymentMethod,
+        "estimatedCompletion": transaction.EstimatedCompletion,
+    }
+    // Slot dan antar-jemput yang tidak dikirim tetap memakai nilai lama
+    if transaction.PickupSlot != nil {
+        fields["pickupSlot"] = transaction.PickupSlot
+    }
+    if transaction.Pickup != nil {
+        fields["pickup"] = transaction.Pickup
+    }
+    if transaction.Delivery != nil {
+        fields["delivery"] = transaction.Delivery
+    }
+    var result *mongo.UpdateResult
+    for attempt := 1; ; attempt++ {
+        fields["items"] = transaction.Items
+        update := bson.M{"$set": fields}
+        if len(transaction.TagCodes) > 0 {
+            fields["tagCodes"] = transaction.TagCodes
+        } else {
+            delete(fields, "tagCodes")
+            update["$unset"] = bson.M{"tagCodes": ""}
+        }
+
+        result, err = config.TransactionCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+        if !isTagCodeConflict(err) || attempt == 5 {
+            break
+        }
+        // Kode tag baru saja dipakai transaksi lain, buat ulang kode otomatis dan periksa lagi kode dari kasir
+        if err := reassignTagCodes(ctx, objID, &transaction, originalItems); err != nil {
+            http.Error(w, err.Error(), tagCodeErrorStatus(err))
+            return
+        }
+    }
     if err != nil {
         http.Error(w, "Gagal memperbarui transaksi: "+err.Error(), http.StatusInternalServerError)
         return
     }
-    if result.ModifiedCount == 0 {
+    if result.MatchedCount == 0 {
         http.Error(w, "Transaksi tidak ditemukan untuk diperbarui", http.StatusNotFound)
         return
     }
 
-    // Ambil kembali data transaksi yang telah diperbarui
-    err = config.TransactionCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&transaction)
+    // Ambil kembali data transaksi yang telah diperbarui, status pelunasan dihitung ulang karena total bisa berubah
+    transaction, err = refreshPaymentStatus(ctx, objID)
     if err != nil {
         http.Error(w, "Gagal mendapatkan transaksi setelah update: "+err.Error(), http.StatusInternalServerError)
         return
@@ -246,3 +403,218 @@ func DeleteTransaction(w http.ResponseWriter, r *http.Request) {
 		"deleted_id": objID.Hex(),
 	})
 }
+
+// applyServiceToItem menghitung harga item berdasarkan layanan dan tingkat kecepatan yang dipilih
+func applyServiceToItem(item *models.TransactionItem, service models.Service) error {
+	tier, ok := findSpeedTier(service, item.SpeedTier)
+	if !ok {
+		return fmt.Errorf("Tingkat kecepatan '%s' tidak tersedia untuk layanan %s", item.SpeedTier, service.ServiceName)
+	}
+
+	multiplier := tier.PriceMultiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+
+	item.Service = service
+	item.SpeedTier = tier.Name
+	item.UnitPrice = service.UnitPrice * multiplier
+	item.Surcharge = tier.Surcharge
+	item.TurnaroundHours = tier.TurnaroundHours
+	item.TotalPrice = float64(item.Quantity)*item.UnitPrice + item.Surcharge
+	return nil
+}
+
+// recalculateTotal menghitung total transaksi dari subtotal item, ongkos antar-jemput, dan biaya penyimpanan
+func recalculateTotal(transaction *models.Transaction) {
+	transaction.DeliveryFee = logisticsFee(*transaction)
+	transaction.TotalAmount = transaction.Subtotal + transaction.DeliveryFee + transaction.StorageFee
+}
+
+// estimateCompletion mengambil durasi pengerjaan terlama dari semua item lalu menghitungnya dalam jam kerja toko
+func estimateCompletion(start time.Time, items []models.TransactionItem, settings models.ShopSettings) time.Time {
+	var longest int
+	for _, item := range items {
+		if item.TurnaroundHours > longest {
+			longest = item.TurnaroundHours
+		}
+	}
+	if longest == 0 {
+		return time.Time{}
+	}
+	return utils.AddWorkingHours(start, longest, settings)
+}
+
+// assignPickupSlot memvalidasi slot pengambilan dari request atau membuat slot pertama setelah cucian selesai
+func assignPickupSlot(transaction *models.Transaction, settings models.ShopSettings) error {
+	slotLength := time.Duration(settings.PickupSlotMinutes) * time.Minute
+
+	if transaction.PickupSlot != nil {
+		slot := transaction.PickupSlot
+		if slot.End.IsZero() {
+			slot.End = slot.Start.Add(slotLength)
+		}
+		if !slot.End.After(slot.Start) {
+			return fmt.Errorf("Slot pengambilan tidak valid")
+		}
+		if !transaction.EstimatedCompletion.IsZero() && slot.End.Before(transaction.EstimatedCompletion) {
+			return fmt.Errorf("Slot pengambilan lebih awal dari perkiraan selesai")
+		}
+		return nil
+	}
+
+	if transaction.EstimatedCompletion.IsZero() {
+		return nil
+	}
+
+	// Bulatkan ke atas ke jam penuh berikutnya agar mudah dijanjikan ke customer,
+	// lalu geser ke jam buka berikutnya jika jatuh di luar jam kerja atau hari libur
+	start := transaction.EstimatedCompletion.In(utils.ShopLocation(settings))
+	rounded := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, start.Location())
+	if rounded.Before(start) {
+		rounded = rounded.Add(time.Hour)
+	}
+	start = utils.AddWorkingHours(rounded, 0, settings)
+	transaction.PickupSlot = &models.TimeSlot{Start: start, End: start.Add(slotLength)}
+	return nil
+}
+
+// Urutan status transaksi, status hanya boleh bergerak maju
+var transactionStatusOrder = map[string]int{
+	models.TransactionPending:    1,
+	models.TransactionProcessing: 2,
+	models.TransactionReady:      3,
+	models.TransactionCompleted:  4,
+}
+
+// Fungsi untuk memperbarui status transaksi (Pending -> Processing -> Ready -> Completed)
+func UpdateTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "ID tidak disediakan", http.StatusBadRequest)
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+	if _, ok := transactionStatusOrder[req.Status]; !ok {
+		http.Error(w, "Status transaksi tidak dikenal", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var transaction models.Transaction
+	err = config.TransactionCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&transaction)
+	if err != nil {
+		http.Error(w, "Transaksi tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	if transactionStatusOrder[req.Status] <= transactionStatusOrder[transaction.Status] {
+		http.Error(w, fmt.Sprintf("Status tidak bisa diubah dari %s ke %s", transaction.Status, req.Status), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	set := bson.M{"status": req.Status}
+	switch req.Status {
+	case models.TransactionReady:
+		set["readyAt"] = now
+	case models.TransactionCompleted:
+		set["completedAt"] = now
+		if transaction.ReadyAt == nil {
+			set["readyAt"] = now
+		}
+	}
+
+	_, err = config.TransactionCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": set})
+	if err != nil {
+		http.Error(w, "Gagal memperbarui status transaksi", http.StatusInternalServerError)
+		return
+	}
+
+	// Beri tahu customer bahwa cucian siap diambil
+	if req.Status == models.TransactionReady {
+		transaction.Status = req.Status
+		queueNotification(ctx, transaction, services.EventOrderReady, services.NotificationData{})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Status transaksi berhasil diperbarui",
+		"id":      objID.Hex(),
+		"status":  req.Status,
+	})
+}
+
+// Fungsi untuk mendapatkan transaksi yang jatuh tempo hari ini, terlambat, dan siap tetapi belum diambil
+func GetDueTransactions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	settings := loadShopSettings(ctx)
+	now := time.Now().In(utils.ShopLocation(settings))
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	endOfDay := startOfDay.AddDate(0, 0, 1)
+	inProgress := bson.M{"$in": []string{models.TransactionPending, models.TransactionProcessing}}
+
+	dueToday, err := findTransactions(ctx, bson.M{
+		"status":              inProgress,
+		"estimatedCompletion": bson.M{"$gte": startOfDay, "$lt": endOfDay},
+	})
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan data transaksi", http.StatusInternalServerError)
+		return
+	}
+
+	overdue, err := findTransactions(ctx, bson.M{
+		"status":              inProgress,
+		"estimatedCompletion": bson.M{"$gt": time.Time{}, "$lt": now},
+	})
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan data transaksi", http.StatusInternalServerError)
+		return
+	}
+
+	readyNotCollected, err := findTransactions(ctx, bson.M{"status": models.TransactionReady})
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan data transaksi", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"dueToday":          dueToday,
+		"overdue":           overdue,
+		"readyNotCollected": readyNotCollected,
+	})
+}
+
+// findTransactions mengambil transaksi sesuai filter, diurutkan dari perkiraan selesai paling awal
+func findTransactions(ctx context.Context, filter bson.M) ([]models.Transaction, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "estimatedCompletion", Value: 1}})
+	cursor, err := config.TransactionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transactions := []models.Transaction{}
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fungsi untuk mendapatkan daftar kurir (tanpa password)
+func GetCouriers(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"password": 0})
+	cursor, err := config.UserCollection.Find(ctx, bson.M{"role": models.RoleCourier}, opts)
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan data kurir", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	couriers := []models.User{}
+	if err := cursor.All(ctx, &couriers); err != nil {
+		http.Error(w, "Gagal membaca data kurir", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(couriers)
+}
+
+// Fungsi untuk mengubah role user (khusus admin)
+func UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID primitive.ObjectID `json:"userId"`
+		Role   string             `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Input tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	switch req.Role {
+	case models.RoleAdmin, models.RoleStaff, models.RoleCourier:
+	default:
+		http.Error(w, "Role tidak dikenal", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := config.UserCollection.UpdateOne(ctx, bson.M{"_id": req.UserID}, bson.M{"$set": bson.M{"role": req.Role}})
+	if err != nil {
+		http.Error(w, "Gagal memperbarui role user", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "User tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Role user berhasil diperbarui"})
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"laundry-pos/config"
+	"laundry-pos/models"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newWebhookEvent membuat catatan notifikasi. Gateway mengirim ulang payload yang persis sama,
+// sehingga hash payload dipakai sebagai kunci idempoten.
+func newWebhookEvent(provider string, body []byte, now time.Time) models.WebhookEvent {
+	sum := sha256.Sum256(body)
+	return models.WebhookEvent{
+		ID:         provider + ":" + hex.EncodeToString(sum[:]),
+		Provider:   provider,
+		Payload:    string(body),
+		ReceivedAt: now,
+	}
+}
+
+// storeWebhookEvent menyimpan notifikasi baru. Jika notifikasi yang sama sudah ada, jumlah percobaan ditambah
+// dan data yang tersimpan dikembalikan dengan duplicate = true.
+// Payload notifikasi yang ditolak tidak disimpan karena siapa pun bisa mengirimnya, catatannya dihapus otomatis
+// oleh index TTL. Notifikasi yang lolos verifikasi selalu menimpa data hasil verifikasi yang tersimpan,
+// sehingga payload yang sebelumnya ditolak (misalnya sebelum token callback diatur) tetap bisa diproses.
+func storeWebhookEvent(ctx context.Context, event models.WebhookEvent) (models.WebhookEvent, bool, error) {
+	verified := event.Result != models.WebhookRejected
+	if !verified {
+		event.Payload = ""
+	}
+	event.Attempts = 1
+	_, err := config.WebhookEventCollection.InsertOne(ctx, event)
+	if err == nil {
+		return event, false, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return event, false, err
+	}
+
+	update := bson.M{"$inc": bson.M{"attempts": 1}}
+	if verified {
+		update["$set"] = bson.M{
+			"orderId":     event.OrderID,
+			"status":      event.Status,
+			"grossAmount": event.GrossAmount,
+			"paymentType": event.PaymentType,
+			"payload":     event.Payload,
+		}
+	}
+	var existing models.WebhookEvent
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = config.WebhookEventCollection.FindOneAndUpdate(ctx, bson.M{"_id": event.ID}, update, opts).Decode(&existing)
+	if err != nil {
+		return event, false, err
+	}
+	// Notifikasi yang belum selesai diproses (misalnya server mati di tengah jalan atau sebelumnya ditolak) diproses lagi
+	duplicate := existing.ProcessedAt != nil && existing.Result != models.WebhookFailed
+	return existing, duplicate, nil
+}
+
+// processWebhookEvent menerapkan status dari notifikasi lalu mencatat hasilnya
+func processWebhookEvent(ctx context.Context, event models.WebhookEvent) (string, error) {
+	result, applyErr := applyGatewayStatus(ctx, event.OrderID, event.Status)
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"result": result, "processedAt": now}}
+	if applyErr != nil {
+		update["$set"].(bson.M)["error"] = applyErr.Error()
+	} else {
+		update["$unset"] = bson.M{"error": ""}
+	}
+	// Hasil "applied" tidak ditimpa oleh pemrosesan ulang yang hanya menemukan status yang sama
+	filter := bson.M{"_id": event.ID}
+	if result != models.WebhookApplied {
+		filter["result"] = bson.M{"$ne": models.WebhookApplied}
+	}
+	if _, err := config.WebhookEventCollection.UpdateOne(ctx, filter, update); err != nil && applyErr == nil {
+		return result, err
+	}
+	return result, applyErr
+}
+
+// Fungsi untuk melihat notifikasi payment gateway yang tersimpan, bisa difilter dengan order_id, provider, dan result
+func GetWebhookEvents(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	query := r.URL.Query()
+	if orderID := query.Get("order_id"); orderID != "" {
+		filter["orderId"] = orderID
+	}
+	if provider := query.Get("provider"); provider != "" {
+		filter["provider"] = provider
+	}
+	if result := query.Get("result"); result != "" {
+		filter["result"] = result
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "receivedAt", Value: -1}}).SetLimit(200)
+	cursor, err := config.WebhookEventCollection.Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan notifikasi gateway", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	events := []models.WebhookEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		http.Error(w, "Gagal membaca notifikasi gateway", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// Fungsi untuk memutar ulang notifikasi yang tersimpan, misalnya setelah pemrosesan gagal.
+// Urutan status tetap dijaga sehingga notifikasi lama tidak menimpa status yang lebih baru.
+func ReplayWebhookEvent(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	id := r.URL.Query().Get("id")
+	var event models.WebhookEvent
+	err := config.WebhookEventCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "Notifikasi tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Gagal mendapatkan notifikasi", http.StatusInternalServerError)
+		return
+	}
+	if event.Result == models.WebhookRejected || event.OrderID == "" {
+		http.Error(w, "Notifikasi yang tidak lolos verifikasi tidak bisa diputar ulang", http.StatusBadRequest)
+		return
+	}
+
+	config.WebhookEventCollection.UpdateOne(ctx, bson.M{"_id": event.ID}, bson.M{"$inc": bson.M{"attempts": 1}})
+	result, err := processWebhookEvent(ctx, event)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id": event.ID, "result": result})
+}
@@ -1,8 +1,10 @@
 package middleware
 
 import (
+    "laundry-pos/models"
     "laundry-pos/utils"
     "net/http"
+    "os"
 )
 // EnableCORS menangani header CORS agar frontend dapat mengakses API
 func EnableCORS(next http.Handler) http.Handler {
@@ -27,7 +29,7 @@ func EnableCORS(next http.Handler) http.Handler {
         }
 
         w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 
         // Tangani preflight request (OPTIONS)
         if r.Method == http.MethodOptions {
@@ -50,8 +52,34 @@ func RoleMiddleware(requiredRole string, next http.Handler) http.Handler {
             http.Error(w, "Unauthorized: Token Format Salah", http.StatusUnauthorized)
             return
         }
+
+        claims, err := utils.ValidateJWT(token[7:])
+        if err != nil {
+            http.Error(w, "Invalid token", http.StatusUnauthorized)
+            return
+        }
+
+        // Admin boleh mengakses semua rute
+        if claims.Role != requiredRole && claims.Role != models.RoleAdmin {
+            http.Error(w, "Access denied", http.StatusForbidden)
+            return
+        }
+
         // Proceed to the next handler if authorized
-        next.ServeHTTP(w, r)
+        next.ServeHTTP(w, r.WithContext(utils.WithClaims(r.Context(), claims)))
+    })
+}
+
+// CronMiddleware mengizinkan Vercel Cron (Authorization: Bearer <CRON_SECRET>) atau admin yang login
+func CronMiddleware(next http.Handler) http.Handler {
+    adminOnly := RoleMiddleware(models.RoleAdmin, next)
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        secret := os.Getenv("CRON_SECRET")
+        if secret != "" && r.Header.Get("Authorization") == "Bearer "+secret {
+            next.ServeHTTP(w, r)
+            return
+        }
+        adminOnly.ServeHTTP(w, r)
     })
 }
 
@@ -73,16 +101,29 @@ func AuthMiddleware(next http.Handler) http.Handler {
         token = token[7:]
 
         // Verifikasi token
-        _, err := utils.ValidateJWT(token)
+        claims, err := utils.ValidateJWT(token)
         if err != nil {
             http.Error(w, "Invalid token", http.StatusUnauthorized)
             return
         }
 
-        next.ServeHTTP(w, r)
+        // Simpan klaim agar handler tahu siapa pengguna yang login
+        next.ServeHTTP(w, r.WithContext(utils.WithClaims(r.Context(), claims)))
     })
 }
 
+// StaffMiddleware seperti AuthMiddleware tetapi menolak kurir, yang hanya boleh mengakses tugas antar-jemputnya sendiri
+func StaffMiddleware(next http.Handler) http.Handler {
+    return AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        claims, ok := utils.ClaimsFromContext(r.Context())
+        if !ok || claims.Role == models.RoleCourier {
+            http.Error(w, "Access denied", http.StatusForbidden)
+            return
+        }
+        next.ServeHTTP(w, r)
+    }))
+}
+
 
 // func AuthMiddleware(next http.Handler) http.Handler {
 //     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -13,35 +13,122 @@ type User struct {
 	Role     string             `json:"role" bson:"role"` // Contoh: "admin" atau "staff"
 }
 
+// Role pengguna
+const (
+	RoleAdmin   = "admin"
+	RoleStaff   = "staff"
+	RoleCourier = "courier" // Kurir antar-jemput cucian
+)
+
 // Model untuk Customer
 type Customer struct {
 	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	FullName    string             `json:"fullName" bson:"fullName"`       // Nama lengkap customer
 	Email       string             `json:"email" bson:"email"`             // Email customer
 	PhoneNumber string             `json:"phoneNumber" bson:"phoneNumber"` // Nomor telepon
+	Language    string             `json:"language" bson:"language"`       // Bahasa notifikasi: "id" atau "en"
+	NotifyVia   string             `json:"notifyVia" bson:"notifyVia"`     // Kanal notifikasi: "whatsapp", "sms", "email", atau "none"
 }
 
-// Model untuk Inventory (Stok barang)
+// Model untuk Service (Layanan laundry dan daftar harganya)
 type Service struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	ServiceName string             `json:"serviceName" bson:"serviceName"`
-	Description string             `json:"description" bson:"description"`
-	UnitPrice   float64            `json:"unitPrice" bson:"unitPrice"`
-	Unit        string             `json:"unit" bson:"unit"` // Misalnya, "kg", "item", dll.
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ServiceName     string             `json:"serviceName" bson:"serviceName"`
+	Description     string             `json:"description" bson:"description"`
+	UnitPrice       float64            `json:"unitPrice" bson:"unitPrice"`
+	Unit            string             `json:"unit" bson:"unit"`                       // Misalnya, "kg", "item", dll.
+	TurnaroundHours int                `json:"turnaroundHours" bson:"turnaroundHours"` // Durasi pengerjaan reguler dalam jam
+	SpeedTiers      []SpeedTier        `json:"speedTiers" bson:"speedTiers,omitempty"` // Pilihan express/kilat beserta biayanya
+}
+
+// Pilihan tingkat kecepatan layanan
+const (
+	SpeedRegular = "regular"
+	SpeedExpress = "express"
+	SpeedKilat   = "kilat"
+)
+
+// Model untuk tingkat kecepatan layanan (regular, express, kilat)
+type SpeedTier struct {
+	Name            string  `json:"name" bson:"name"`                       // "regular", "express", atau "kilat"
+	PriceMultiplier float64 `json:"priceMultiplier" bson:"priceMultiplier"` // Pengali harga satuan, 0 dianggap 1
+	Surcharge       float64 `json:"surcharge" bson:"surcharge"`             // Biaya tambahan tetap per item transaksi
+	TurnaroundHours int     `json:"turnaroundHours" bson:"turnaroundHours"` // Janji durasi pengerjaan dalam jam
 }
 
 
 // Model untuk Transaction (Transaksi)
 type Transaction struct {
 	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderNumber     string             `json:"orderNumber" bson:"orderNumber,omitempty"` // Nomor order berurutan, misalnya LDR-20261018-0042
+	TrackingCode    string             `json:"trackingCode" bson:"trackingCode,omitempty"` // Kode verifikasi untuk lacak order tanpa login
+	TrackingToken   string             `json:"trackingToken,omitempty" bson:"-"`         // Token link pelacakan, tidak disimpan
 	CustomerID      primitive.ObjectID `json:"customerId" bson:"customerId"`
+	CashierID       primitive.ObjectID `json:"cashierId,omitempty" bson:"cashierId,omitempty"` // Kasir yang menerima order
 	Customer        Customer           `json:"customer" bson:"customer,omitempty"` // Tambahkan ini untuk menyimpan informasi customer
 	TransactionDate time.Time          `json:"transactionDate" bson:"transactionDate"`
 	Items           []TransactionItem  `json:"items" bson:"items"`       // Daftar item dalam transaksi
+	TagCodes        []string           `json:"-" bson:"tagCodes,omitempty"` // Salinan kode tag semua potongan, dijaga unik oleh index
+	Subtotal        float64            `json:"subtotal" bson:"subtotal"`       // Total harga item sebelum ongkos antar-jemput
+	DeliveryFee     float64            `json:"deliveryFee" bson:"deliveryFee"` // Total ongkos jemput dan antar
+	StorageFee      float64            `json:"storageFee" bson:"storageFee,omitempty"` // Biaya penyimpanan cucian yang lama tidak diambil
 	TotalAmount     float64            `json:"totalAmount" bson:"totalAmount"`
+	AmountPaid      float64            `json:"amountPaid" bson:"amountPaid"`       // Jumlah dari pembayaran yang sudah settle
+	PaymentStatus   string             `json:"paymentStatus" bson:"paymentStatus"` // "unpaid", "partial", atau "paid"
 	PaymentMethod   string             `json:"paymentMethod" bson:"paymentMethod"`
 	SnapURL         string             `json:"snap_url" bson:"snap_url"` // URL pembayaran Midtrans
 	Status          string             `json:"status" bson:"status"`     // Status transaksi
+	EstimatedCompletion time.Time      `json:"estimatedCompletion" bson:"estimatedCompletion"` // Perkiraan waktu selesai
+	PickupSlot      *TimeSlot          `json:"pickupSlot,omitempty" bson:"pickupSlot,omitempty"`   // Slot pengambilan yang dijanjikan
+	ReadyAt         *time.Time         `json:"readyAt,omitempty" bson:"readyAt,omitempty"`         // Waktu cucian siap diambil
+	CompletedAt     *time.Time         `json:"completedAt,omitempty" bson:"completedAt,omitempty"` // Waktu cucian diambil customer
+	Pickup          *Logistics         `json:"pickup,omitempty" bson:"pickup,omitempty"`           // Penjemputan cucian di alamat customer
+	Delivery        *Logistics         `json:"delivery,omitempty" bson:"delivery,omitempty"`       // Pengantaran cucian ke alamat customer
+	PendingChargeAt *time.Time         `json:"-" bson:"pendingChargeAt,omitempty"` // Kunci sementara saat tagihan online sedang dibuat
+}
+
+// Status siklus transaksi
+const (
+	TransactionPending    = "Pending"    // Cucian diterima, belum diproses
+	TransactionProcessing = "Processing" // Sedang dicuci/disetrika
+	TransactionReady      = "Ready"      // Siap diambil
+	TransactionCompleted  = "Completed"  // Sudah diambil customer
+)
+
+// Jenis tugas kurir dan statusnya
+const (
+	LegPickup   = "pickup"
+	LegDelivery = "delivery"
+
+	LogisticsScheduled = "Scheduled"
+	LogisticsDone      = "Done"
+)
+
+// Model untuk penjemputan/pengantaran cucian oleh kurir
+type Logistics struct {
+	Address     string             `json:"address" bson:"address"`
+	Window      TimeSlot           `json:"window" bson:"window"`         // Rentang waktu jemput/antar
+	DistanceKm  float64            `json:"distanceKm" bson:"distanceKm"` // Jarak dari toko untuk menentukan zona
+	Zone        string             `json:"zone" bson:"zone"`             // Zona tarif yang dipakai
+	Fee         float64            `json:"fee" bson:"fee"`
+	CourierID   primitive.ObjectID `json:"courierId" bson:"courierId,omitempty"`
+	CourierName string             `json:"courierName" bson:"courierName,omitempty"`
+	Status      string             `json:"status" bson:"status"` // "Scheduled" atau "Done"
+	DoneAt      *time.Time         `json:"doneAt,omitempty" bson:"doneAt,omitempty"`
+	ProofNote   string             `json:"proofNote,omitempty" bson:"proofNote,omitempty"` // Catatan bukti (penerima, foto, dll.)
+}
+
+// Status pelunasan transaksi
+const (
+	PaymentUnpaid  = "unpaid"
+	PaymentPartial = "partial"
+	PaymentPaid    = "paid"
+)
+
+// Model untuk rentang waktu (misalnya slot pengambilan)
+type TimeSlot struct {
+	Start time.Time `json:"start" bson:"start"`
+	End   time.Time `json:"end" bson:"end"`
 }
 
 
@@ -53,6 +140,19 @@ type TransactionItem struct {
 	Quantity  int                `json:"quantity" bson:"quantity"`
 	UnitPrice float64            `json:"unitPrice" bson:"unitPrice"`
 	TotalPrice float64           `json:"totalPrice" bson:"totalPrice"`
+	SpeedTier string             `json:"speedTier" bson:"speedTier"`             // Tingkat kecepatan yang dipilih
+	Surcharge float64            `json:"surcharge" bson:"surcharge"`             // Biaya tambahan dari tingkat kecepatan
+	TurnaroundHours int          `json:"turnaroundHours" bson:"turnaroundHours"` // Durasi pengerjaan yang dijanjikan
+	Pieces    []GarmentPiece     `json:"pieces,omitempty" bson:"pieces,omitempty"` // Potongan cucian yang dilacak per tag
+}
+
+// Model untuk satu potong cucian (jas, sepatu, bedcover) yang dilacak dengan kode tag
+type GarmentPiece struct {
+	TagCode     string `json:"tagCode" bson:"tagCode"`         // Kode tag yang ditempel pada potongan
+	Description string `json:"description" bson:"description"` // Misalnya "Kemeja lengan panjang"
+	Colour      string `json:"colour" bson:"colour"`
+	Brand       string `json:"brand" bson:"brand"`
+	DamageNotes string `json:"damageNotes" bson:"damageNotes"` // Kerusakan/noda yang dicatat saat diterima
 }
 
 
@@ -64,9 +164,36 @@ type Payment struct {
 	SnapURL       string             `json:"snap_url" bson:"snap_url"`      // URL untuk pembayaran di Midtrans
 	Status        string             `json:"status" bson:"status"`         // Status pembayaran: Pending, Success, Failed
 	CreatedAt     time.Time          `json:"created_at" bson:"created_at"` // Waktu pembuatan pembayaran
+	SettledAt     *time.Time         `json:"settled_at,omitempty" bson:"settled_at,omitempty"` // Waktu dana diterima (settle)
 	PaymentMethod string             `json:"payment_method" bson:"payment_method,omitempty"` // Metode pembayaran jika diperlukan
+	Channel         string             `json:"channel" bson:"channel,omitempty"`                   // midtrans, xendit, cash, transfer, edc, atau qris
+	AmountTendered  float64            `json:"amount_tendered,omitempty" bson:"amount_tendered,omitempty"` // Uang yang diterima kasir (tunai)
+	ChangeGiven     float64            `json:"change_given,omitempty" bson:"change_given,omitempty"`       // Kembalian yang diberikan
+	ReferenceNumber string             `json:"reference_number,omitempty" bson:"reference_number,omitempty"` // No. referensi transfer/EDC/QRIS
+	CashierID       primitive.ObjectID `json:"cashier_id,omitempty" bson:"cashier_id,omitempty"`           // Kasir yang mencatat pembayaran manual
+	ShiftID         primitive.ObjectID `json:"shift_id,omitempty" bson:"shift_id,omitempty"`               // Shift kasir tempat uang tunai diterima
+	Note            string             `json:"note,omitempty" bson:"note,omitempty"`
+	RefundedAmount  float64            `json:"refunded_amount,omitempty" bson:"refunded_amount,omitempty"` // Total yang sudah dikembalikan ke customer
+	IdempotencyKey  string             `json:"-" bson:"idempotency_key,omitempty"`                         // Header Idempotency-Key dari kasir/frontend
+	ExpiresAt       *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`           // Batas waktu link Snap
+	QRString        string             `json:"qr_string,omitempty" bson:"qr_string,omitempty"`             // Isi QRIS dari Core API
+	QRImageURL      string             `json:"qr_image_url,omitempty" bson:"qr_image_url,omitempty"`       // Gambar QRIS dari gateway
+	VABank          string             `json:"va_bank,omitempty" bson:"va_bank,omitempty"`                 // Bank virtual account
+	VANumber        string             `json:"va_number,omitempty" bson:"va_number,omitempty"`             // Nomor virtual account
+	ReconcileAttempts int              `json:"reconcile_attempts,omitempty" bson:"reconcile_attempts,omitempty"` // Berapa kali job rekonsiliasi memeriksa pembayaran ini
+	NextReconcileAt   *time.Time       `json:"next_reconcile_at,omitempty" bson:"next_reconcile_at,omitempty"`   // Jadwal pemeriksaan berikutnya, makin jarang setiap kali masih pending
 }
 
+// Saluran pembayaran
+const (
+	PaymentChannelMidtrans = "midtrans"
+	PaymentChannelXendit   = "xendit"
+	PaymentChannelCash     = "cash"
+	PaymentChannelTransfer = "transfer"
+	PaymentChannelEDC      = "edc"
+	PaymentChannelQRIS     = "qris" // QRIS statis yang dicek manual oleh kasir
+)
+
 
 
 // Model untuk informasi Customer dalam pembayaran
@@ -74,4 +201,220 @@ type PaymentCustomer struct {
     Name  string `json:"name" bson:"name"`
     Email string `json:"email" bson:"email"`
     Phone string `json:"phone" bson:"phone"`
-}
\ No newline at end of file
+}
+
+// Model untuk pengaturan toko (jam kerja dan hari libur)
+type ShopSettings struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ShopName          string             `json:"shopName" bson:"shopName"`           // Ditampilkan di kepala struk
+	ShopAddress       string             `json:"shopAddress" bson:"shopAddress"`
+	ShopPhone         string             `json:"shopPhone" bson:"shopPhone"`
+	InvoicePrefix     string             `json:"invoicePrefix" bson:"invoicePrefix"` // Awalan nomor order, misalnya "LDR"
+	ReceiptFooter     string             `json:"receiptFooter" bson:"receiptFooter"` // Catatan di bagian bawah struk
+	Timezone          string             `json:"timezone" bson:"timezone"`                   // Contoh: "Asia/Jakarta"
+	OpenTime          string             `json:"openTime" bson:"openTime"`                   // Jam buka, format "HH:MM"
+	CloseTime         string             `json:"closeTime" bson:"closeTime"`                 // Jam tutup, format "HH:MM"
+	ClosedDays        []int              `json:"closedDays" bson:"closedDays"`               // Hari tutup mingguan, 0 = Minggu ... 6 = Sabtu
+	Holidays          []string           `json:"holidays" bson:"holidays"`                   // Tanggal libur, format "YYYY-MM-DD"
+	PickupSlotMinutes int                `json:"pickupSlotMinutes" bson:"pickupSlotMinutes"` // Panjang slot pengambilan
+	DeliveryZones     []DeliveryZone     `json:"deliveryZones" bson:"deliveryZones"`         // Tarif antar-jemput per zona jarak
+
+	UncollectedReminderDays int     `json:"uncollectedReminderDays" bson:"uncollectedReminderDays"` // Ingatkan jika siap tapi belum diambil setelah N hari
+	UnpaidReminderDays      int     `json:"unpaidReminderDays" bson:"unpaidReminderDays"`           // Ingatkan jika belum dibayar setelah M hari
+	StorageFeeAfterDays     int     `json:"storageFeeAfterDays" bson:"storageFeeAfterDays"`         // Biaya penyimpanan mulai dihitung setelah hari ke-
+	StorageFeePerDay        float64 `json:"storageFeePerDay" bson:"storageFeePerDay"`               // 0 berarti tanpa biaya penyimpanan
+	PaymentProvider         string  `json:"paymentProvider" bson:"paymentProvider"`                 // Payment gateway aktif: "midtrans" atau "xendit"
+}
+
+// Model untuk zona tarif antar-jemput
+type DeliveryZone struct {
+	Name          string  `json:"name" bson:"name"`
+	MaxDistanceKm float64 `json:"maxDistanceKm" bson:"maxDistanceKm"` // Batas jarak zona ini
+	Fee           float64 `json:"fee" bson:"fee"`
+}
+
+// Status pengiriman notifikasi di outbox
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+)
+
+// Model untuk notifikasi ke customer yang antre di outbox
+type Notification struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TransactionID primitive.ObjectID `json:"transactionId" bson:"transactionId"`
+	CustomerID    primitive.ObjectID `json:"customerId" bson:"customerId"`
+	Event         string             `json:"event" bson:"event"`     // Misalnya "order_ready"
+	Channel       string             `json:"channel" bson:"channel"` // "whatsapp", "sms", atau "email"
+	Recipient     string             `json:"recipient" bson:"recipient"`
+	Subject       string             `json:"subject" bson:"subject"`
+	Body          string             `json:"body" bson:"body"`
+	Status        string             `json:"status" bson:"status"` // "pending", "sent", atau "failed"
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	LastError     string             `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	NextAttemptAt time.Time          `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	SentAt        *time.Time         `json:"sentAt,omitempty" bson:"sentAt,omitempty"`
+}
+
+// Jenis pengingat yang dikirim oleh job terjadwal
+const (
+	ReminderUncollected = "uncollected"
+	ReminderUnpaid      = "unpaid"
+)
+
+// Model catatan pengingat agar job tidak mengirim pengingat yang sama dua kali
+type Reminder struct {
+	ID            string             `json:"id" bson:"_id"` // Kunci unik: jenis:transaksi:urutan
+	TransactionID primitive.ObjectID `json:"transactionId" bson:"transactionId"`
+	Kind          string             `json:"kind" bson:"kind"`
+	Sequence      int                `json:"sequence" bson:"sequence"` // Pengingat ke berapa untuk transaksi ini
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Alasan pengembalian dana
+const (
+	RefundReasonDamaged    = "damaged"    // Cucian rusak
+	RefundReasonLost       = "lost"       // Cucian hilang
+	RefundReasonCancelled  = "cancelled"  // Order dibatalkan
+	RefundReasonOvercharge = "overcharge" // Salah tagih atau kelebihan bayar
+	RefundReasonDuplicate  = "duplicate"  // Pembayaran ganda
+	RefundReasonOther      = "other"
+)
+
+// Status pengajuan pengembalian dana
+const (
+	RefundRequested = "requested" // Menunggu persetujuan admin
+	RefundApproved  = "approved"  // Disetujui dan sedang diproses
+	RefundRejected  = "rejected"
+	RefundCompleted = "completed" // Dana sudah dikembalikan (gateway berhasil atau uang tunai sudah diserahkan)
+	RefundFailed    = "failed"    // Disetujui tapi gateway menolak, boleh disetujui ulang
+)
+
+// Model pengembalian dana atas sebuah pembayaran
+type Refund struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	PaymentID     primitive.ObjectID `json:"paymentId" bson:"paymentId"`
+	TransactionID primitive.ObjectID `json:"transactionId" bson:"transactionId"`
+	OrderID       string             `json:"orderId" bson:"orderId"` // order_id pembayaran yang dikembalikan
+	Amount        float64            `json:"amount" bson:"amount"`
+	ReasonCode    string             `json:"reasonCode" bson:"reasonCode"`
+	Note          string             `json:"note,omitempty" bson:"note,omitempty"`
+	Method        string             `json:"method" bson:"method"`                           // "gateway" atau "cash_out"
+	RefundKey     string             `json:"refundKey,omitempty" bson:"refundKey,omitempty"` // Kunci idempoten ke gateway
+	ShiftID       primitive.ObjectID `json:"shiftId,omitempty" bson:"shiftId,omitempty"`     // Shift kasir tempat uang tunai dikeluarkan
+	Status        string             `json:"status" bson:"status"`
+	RequestedBy   primitive.ObjectID `json:"requestedBy" bson:"requestedBy"`
+	ApprovedBy    primitive.ObjectID `json:"approvedBy,omitempty" bson:"approvedBy,omitempty"`
+	DecisionNote  string             `json:"decisionNote,omitempty" bson:"decisionNote,omitempty"`
+	LastError     string             `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	DecidedAt     *time.Time         `json:"decidedAt,omitempty" bson:"decidedAt,omitempty"`
+	CompletedAt   *time.Time         `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+}
+
+// Cara pengembalian dana
+const (
+	RefundMethodGateway = "gateway"
+	RefundMethodCashOut = "cash_out"
+)
+
+// Status shift kasir
+const (
+	ShiftOpen   = "open"
+	ShiftClosed = "closed"
+)
+
+// Model shift kasir dan rekap laci uang tunai
+type Shift struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CashierID    primitive.ObjectID `json:"cashierId" bson:"cashierId"`
+	CashierName  string             `json:"cashierName" bson:"cashierName"`
+	Status       string             `json:"status" bson:"status"`
+	OpeningFloat float64            `json:"openingFloat" bson:"openingFloat"` // Uang modal awal di laci
+	OpenedAt     time.Time          `json:"openedAt" bson:"openedAt"`
+	ClosedAt     *time.Time         `json:"closedAt,omitempty" bson:"closedAt,omitempty"`
+	CashSales    float64            `json:"cashSales" bson:"cashSales"`       // Pembayaran tunai yang diterima
+	CashRefunds  float64            `json:"cashRefunds" bson:"cashRefunds"`   // Refund tunai yang dikeluarkan
+	PayIns       float64            `json:"payIns" bson:"payIns"`             // Uang masuk selain penjualan
+	PayOuts      float64            `json:"payOuts" bson:"payOuts"`           // Uang keluar, misalnya beli deterjen
+	ExpectedCash float64            `json:"expectedCash" bson:"expectedCash"` // Modal + penjualan - refund + pay-in - pay-out
+	CountedCash  float64            `json:"countedCash" bson:"countedCash"`   // Uang yang dihitung saat tutup shift
+	Variance     float64            `json:"variance" bson:"variance"`         // Positif berarti lebih, negatif berarti kurang
+	Note         string             `json:"note,omitempty" bson:"note,omitempty"`
+}
+
+// Jenis pergerakan kas di luar penjualan
+const (
+	CashPayIn  = "pay_in"
+	CashPayOut = "pay_out"
+)
+
+// Model uang masuk/keluar laci di luar penjualan dan refund
+type CashMovement struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ShiftID   primitive.ObjectID `json:"shiftId" bson:"shiftId"`
+	Kind      string             `json:"kind" bson:"kind"`
+	Amount    float64            `json:"amount" bson:"amount"`
+	Reason    string             `json:"reason" bson:"reason"`
+	CreatedBy primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Hasil pemrosesan notifikasi payment gateway
+const (
+	WebhookApplied      = "applied"       // Status pembayaran diperbarui
+	WebhookIgnored      = "ignored"       // Status sama atau lebih lama dari yang tersimpan
+	WebhookUnknownOrder = "unknown_order" // Order ID tidak ada di koleksi payments
+	WebhookRejected     = "rejected"      // Signature/token tidak valid, payload tidak disimpan dan catatannya dihapus otomatis
+	WebhookFailed       = "failed"        // Gagal diproses, akan diproses ulang saat gateway mengirim ulang
+)
+
+// Model notifikasi mentah dari payment gateway, disimpan agar bisa diperiksa dan diputar ulang
+type WebhookEvent struct {
+	ID          string     `json:"id" bson:"_id"` // Kunci unik: penyedia:sha256(payload), notifikasi ulang memakai kunci yang sama
+	Provider    string     `json:"provider" bson:"provider"`
+	OrderID     string     `json:"orderId,omitempty" bson:"orderId,omitempty"`
+	Status      string     `json:"status,omitempty" bson:"status,omitempty"` // Status pembayaran menurut notifikasi
+	GrossAmount float64    `json:"grossAmount,omitempty" bson:"grossAmount,omitempty"`
+	PaymentType string     `json:"paymentType,omitempty" bson:"paymentType,omitempty"`
+	Payload     string     `json:"payload" bson:"payload"`
+	Result      string     `json:"result" bson:"result"`
+	Error       string     `json:"error,omitempty" bson:"error,omitempty"`
+	Attempts    int        `json:"attempts" bson:"attempts"` // Berapa kali notifikasi ini diterima atau diputar ulang
+	ReceivedAt  time.Time  `json:"receivedAt" bson:"receivedAt"`
+	ProcessedAt *time.Time `json:"processedAt,omitempty" bson:"processedAt,omitempty"`
+}
+
+// Jenis pergerakan stok bahan habis pakai
+const (
+	StockIn  = "in"  // Pembelian/penerimaan barang
+	StockOut = "out" // Pemakaian, rusak, atau hilang
+)
+
+// Model untuk Inventory bahan habis pakai (deterjen, pewangi, plastik, hanger)
+type InventoryItem struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name         string             `json:"name" bson:"name"`
+	Category     string             `json:"category" bson:"category"`         // Misalnya "deterjen", "pewangi", "kemasan"
+	Unit         string             `json:"unit" bson:"unit"`                 // Misalnya "liter", "kg", "pcs"
+	Stock        float64            `json:"stock" bson:"stock"`               // Hanya berubah lewat pergerakan stok
+	ReorderLevel float64            `json:"reorderLevel" bson:"reorderLevel"` // Stok menipis jika sama atau di bawah angka ini
+	Note         string             `json:"note,omitempty" bson:"note,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt    time.Time          `json:"updatedAt" bson:"updatedAt"`
+	ArchivedAt   *time.Time         `json:"archivedAt,omitempty" bson:"archivedAt,omitempty"` // Barang yang dihapus hanya diarsipkan agar riwayat stok tetap ada
+}
+
+// Model pergerakan stok masuk/keluar
+type StockMovement struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ItemID     primitive.ObjectID `json:"itemId" bson:"itemId"`
+	Kind       string             `json:"kind" bson:"kind"` // "in" atau "out"
+	Quantity   float64            `json:"quantity" bson:"quantity"`
+	StockAfter float64            `json:"stockAfter" bson:"stockAfter"` // Stok setelah pergerakan ini
+	Reason     string             `json:"reason" bson:"reason"`
+	CreatedBy  primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+}
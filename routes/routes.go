@@ -3,6 +3,7 @@ package routes
 import (
 	"laundry-pos/controllers"
 	"laundry-pos/middleware"
+	"laundry-pos/models"
 	"net/http"
 )
 
@@ -37,8 +38,9 @@ func InitRoutes() *http.ServeMux {
 		}
 	})
 
+	// Rute staf memakai StaffMiddleware, akun kurir hanya bisa membuka /courier-jobs dan /courier-job-done
 	// Rute untuk customer
-    router.Handle("/customers", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    router.Handle("/customers", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
 			controllers.AddCustomer(w, r) // Membuat customer baru
@@ -49,7 +51,7 @@ func InitRoutes() *http.ServeMux {
 		}
 	})))
 
-    router.Handle("/customer-id", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    router.Handle("/customer-id", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			controllers.GetCustomerByID(w, r) // Mengambil data customer berdasarkan ID
@@ -64,7 +66,7 @@ func InitRoutes() *http.ServeMux {
 
 
 	// Rute untuk Service
-    router.Handle("/services", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    router.Handle("/services", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
 			controllers.CreateService(w, r) // Tambah layanan baru
@@ -75,7 +77,7 @@ func InitRoutes() *http.ServeMux {
 		}
 	})))
 
-    router.Handle("/service-id", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    router.Handle("/service-id", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			controllers.GetServiceByID(w, r) // Ambil layanan berdasarkan ID
@@ -88,8 +90,54 @@ func InitRoutes() *http.ServeMux {
 		}
 	})))
 
+	// Rute untuk Inventory bahan habis pakai
+    router.Handle("/inventory", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.CreateInventoryItem(w, r) // Tambah barang baru
+		case http.MethodGet:
+			controllers.GetInventoryItems(w, r) // Ambil semua barang
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/inventory-id", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetInventoryItem(w, r) // Ambil barang berdasarkan ID
+		case http.MethodPut:
+			controllers.UpdateInventoryItem(w, r) // Update data barang (tanpa stok)
+		case http.MethodDelete:
+			// Hanya admin yang boleh mengarsipkan barang
+			middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(controllers.DeleteInventoryItem)).ServeHTTP(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/inventory-movements", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.RecordStockMovement(w, r) // Catat stok masuk/keluar
+		case http.MethodGet:
+			controllers.GetStockMovements(w, r) // Riwayat pergerakan stok
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/inventory-low-stock", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetLowStockItems(w, r) // Barang yang perlu dipesan ulang
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
 	// Rute untuk Transaksi
-    router.Handle("/transactions", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    router.Handle("/transactions", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
 			controllers.CreateTransaction(w, r) // Buat transaksi baru
@@ -100,7 +148,7 @@ func InitRoutes() *http.ServeMux {
 		}
 	})))
 
-    router.Handle("/transaction-id", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    router.Handle("/transaction-id", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			controllers.GetTransactionByID(w, r) // Ambil transaksi berdasarkan ID
@@ -113,15 +161,371 @@ func InitRoutes() *http.ServeMux {
 		}
 	})))
 
+    router.Handle("/transaction-status", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			controllers.UpdateTransactionStatus(w, r) // Ubah status pengerjaan transaksi
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/transactions-due", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetDueTransactions(w, r) // Transaksi jatuh tempo, terlambat, dan belum diambil
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute publik untuk customer melacak status order (tanpa login)
+	router.HandleFunc("/track", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.TrackOrder(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Rute untuk mencetak struk customer dan tiket produksi
+    router.Handle("/transaction-receipt", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetTransactionReceipt(w, r) // ESC/POS, HTML, atau PDF
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk melihat riwayat pembayaran dan sisa tagihan transaksi (DP/cicilan)
+    router.Handle("/transaction-payments", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetTransactionPayments(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk mencari pemilik cucian berdasarkan kode tag
+    router.Handle("/garment-tag", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetGarmentByTag(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk antar-jemput dan kurir
+    router.Handle("/transaction-courier", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			controllers.AssignCourier(w, r) // Tugaskan kurir ke penjemputan/pengantaran
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/couriers", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetCouriers(w, r) // Daftar user dengan role kurir
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/courier-jobs", middleware.RoleMiddleware(models.RoleCourier, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetCourierJobs(w, r) // Tugas milik kurir yang login
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/courier-job-done", middleware.RoleMiddleware(models.RoleCourier, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.CompleteCourierJob(w, r) // Tandai jemput/antar selesai
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk mengubah role user (khusus admin)
+    router.Handle("/user-role", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			controllers.UpdateUserRole(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk outbox notifikasi customer
+    router.Handle("/notifications", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetNotifications(w, r) // Lihat isi outbox
+		case http.MethodPost:
+			controllers.ProcessNotifications(w, r) // Kirim ulang notifikasi yang tertunda
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute job terjadwal (Vercel Cron)
+    router.Handle("/cron/reminders", middleware.CronMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodPost:
+			controllers.RunReminders(w, r) // Pengingat cucian belum diambil dan belum dibayar
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/cron/payments-reconcile", middleware.CronMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodPost:
+			controllers.RunPaymentReconciliation(w, r) // Cocokkan pembayaran pending dengan gateway
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/cron/notifications", middleware.CronMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodPost:
+			controllers.ProcessNotifications(w, r) // Kirim notifikasi yang tertunda atau gagal dikirim di background
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk pengaturan toko (jam kerja dan hari libur)
+    router.Handle("/settings", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetSettings(w, r) // Ambil pengaturan toko
+		case http.MethodPut:
+			// Hanya admin yang boleh mengubah jam kerja, biaya, prefix invoice, dan payment gateway
+			middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(controllers.UpdateSettings)).ServeHTTP(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
 	// Rute untuk membuat pembayaran menggunakan Midtrans
-	router.HandleFunc("/create-payment", func(w http.ResponseWriter, r *http.Request) {
+    router.Handle("/create-payment", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
 			controllers.CreatePayment(w, r) // Memanggil fungsi CreatePayment untuk membuat pembayaran
 		default:
 			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		}
-	})
+	})))
+
+	// Rute untuk mencatat pembayaran tunai, transfer, EDC, atau QRIS statis di kasir
+    router.Handle("/record-payment", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.RecordPayment(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk pengajuan dan daftar refund
+    router.Handle("/refunds", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetRefunds(w, r)
+		case http.MethodPost:
+			controllers.CreateRefund(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk menyetujui atau menolak refund (khusus admin)
+    router.Handle("/refund-decision", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			controllers.DecideRefund(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk membatalkan pembayaran (khusus admin)
+    router.Handle("/payment-void", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.VoidPayment(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk membuka shift kasir
+    router.Handle("/shift-open", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.OpenShift(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk melihat shift yang sedang terbuka
+    router.Handle("/shift-current", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetCurrentShift(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk mencatat pay-in/pay-out laci kasir
+    router.Handle("/shift-cash", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.RecordCashMovement(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk menutup shift dan menghitung selisih kas
+    router.Handle("/shift-close", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.CloseShift(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk laporan shift dan selisih kas per kasir
+    router.Handle("/shifts", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetShifts(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute untuk gambar QRIS pembayaran yang ditampilkan di layar POS
+    router.Handle("/payment-qr", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetPaymentQR(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute admin untuk memeriksa dan memutar ulang notifikasi payment gateway
+    router.Handle("/webhook-events", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetWebhookEvents(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/webhook-event-replay", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.ReplayWebhookEvent(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute laporan untuk pemilik toko
+    router.Handle("/sales-report", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetSalesReport(w, r) // Penjualan per hari/minggu/bulan, layanan, metode bayar, dan kasir
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute laporan piutang untuk menagih customer yang belum lunas
+    router.Handle("/receivables-report", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetReceivablesReport(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute ekspor CSV/XLSX untuk akuntan
+    router.Handle("/export-transactions", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.ExportTransactions(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/export-payments", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.ExportPayments(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/export-customers", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.ExportCustomers(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute impor CSV customer dan daftar harga layanan (dryRun=true untuk validasi saja)
+    router.Handle("/import-customers", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.ImportCustomers(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+    router.Handle("/import-services", middleware.RoleMiddleware(models.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.ImportServices(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rute ringkasan KPI untuk halaman dashboard
+    router.Handle("/dashboard", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			controllers.GetDashboard(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})))
 
 	router.HandleFunc("/payments", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -132,7 +536,7 @@ func InitRoutes() *http.ServeMux {
 		}
 	})
 
-	router.Handle("/payment-detail", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	router.Handle("/payment-detail", middleware.StaffMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			controllers.GetPaymentByOrderID(w, r) // Mengambil pembayaran berdasarkan OrderID
@@ -152,5 +556,15 @@ func InitRoutes() *http.ServeMux {
 		}
 	})
 
+	// Handler untuk menerima callback invoice dari Xendit
+	router.HandleFunc("/webhook/xendit", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			controllers.XenditWebhookHandler(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	return router
 }
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// EmailNotifier mengirim email melalui server SMTP
+type EmailNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewEmailNotifier membaca SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, dan SMTP_FROM
+func NewEmailNotifier() (*EmailNotifier, error) {
+	n := &EmailNotifier{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if n.Host == "" || n.From == "" {
+		return nil, fmt.Errorf("SMTP_HOST dan SMTP_FROM belum diatur")
+	}
+	if n.Port == "" {
+		n.Port = "587"
+	}
+	return n, nil
+}
+
+func (n *EmailNotifier) Channel() string {
+	return ChannelEmail
+}
+
+func (n *EmailNotifier) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+
+	to, body := n.compose(msg)
+	return smtp.SendMail(n.Host+":"+n.Port, auth, n.From, []string{to}, body)
+}
+
+// compose menyusun header dan isi email, hasilnya alamat tujuan dan pesan lengkap
+func (n *EmailNotifier) compose(msg Message) (string, []byte) {
+	// Alamat dan subjek berasal dari data customer, buang CR/LF agar tidak bisa menyisipkan header lain
+	to := headerValue(msg.To)
+	headers := []string{
+		"From: " + n.From,
+		"To: " + to,
+		"Subject: " + headerValue(msg.Subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	return to, []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + msg.Body)
+}
+
+// headerValue menghapus baris baru dari nilai header email
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmailComposeStripsHeaderInjection(t *testing.T) {
+	n := &EmailNotifier{From: "toko@laundry.test"}
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"subjek", Message{To: "budi@example.com", Subject: "Order siap\r\nBcc: attacker@example.com", Body: "Halo"}},
+		{"alamat", Message{To: "budi@example.com\nBcc: attacker@example.com", Subject: "Order siap", Body: "Halo"}},
+		{"hanya LF", Message{To: "budi@example.com", Subject: "Order siap\nBcc: attacker@example.com", Body: "Halo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to, raw := n.compose(tt.msg)
+			if strings.ContainsAny(to, "\r\n") {
+				t.Errorf("alamat tujuan memuat baris baru: %q", to)
+			}
+			header := strings.SplitN(string(raw), "\r\n\r\n", 2)[0]
+			for _, line := range strings.Split(header, "\r\n") {
+				if strings.HasPrefix(line, "Bcc:") || strings.Contains(line, "\n") {
+					t.Errorf("header tambahan tersisip: %q", line)
+				}
+			}
+		})
+	}
+}
+
+func TestEmailComposeKeepsBody(t *testing.T) {
+	n := &EmailNotifier{From: "toko@laundry.test"}
+	_, raw := n.compose(Message{To: "budi@example.com", Subject: "Order siap", Body: "Baris 1\r\nBaris 2"})
+	if !strings.HasSuffix(string(raw), "\r\n\r\nBaris 1\r\nBaris 2") {
+		t.Errorf("isi email berubah: %q", raw)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"laundry-pos/models"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Penyedia payment gateway yang didukung
+const (
+	ProviderMidtrans = "midtrans"
+	ProviderXendit   = "xendit"
+	ProviderFake     = "fake"
+)
+
+// Status pembayaran gateway memakai istilah Midtrans karena itulah yang tersimpan di koleksi payments
+const (
+	GatewayPending       = "pending"
+	GatewaySettlement    = "settlement"
+	GatewayExpire        = "expire"
+	GatewayCancel        = "cancel"
+	GatewayDeny          = "deny"
+	GatewayRefund        = "refund"
+	GatewayPartialRefund = "partial_refund"
+)
+
+// Cara pembayaran online: halaman redirect (Snap/invoice) atau langsung tampil di layar POS
+const (
+	ChargeRedirect       = "snap"
+	ChargeQRIS           = "qris"
+	ChargeVirtualAccount = "bank_transfer"
+)
+
+// Bank virtual account yang didukung untuk charge langsung
+var VirtualAccountBanks = map[string]bool{"bca": true, "bni": true, "bri": true, "permata": true, "cimb": true}
+
+// ChargeRequest adalah data untuk membuat link/tagihan pembayaran di gateway
+type ChargeRequest struct {
+	Payment     models.Payment
+	Transaction models.Transaction
+	Customer    models.Customer
+	Start       time.Time
+	Expiry      time.Duration
+	Method      string // ChargeRedirect (bawaan), ChargeQRIS, atau ChargeVirtualAccount
+	Bank        string // Wajib untuk ChargeVirtualAccount
+}
+
+// ChargeResult adalah hasil pembuatan tagihan di gateway
+type ChargeResult struct {
+	RedirectURL string // Halaman pembayaran untuk customer (hanya ChargeRedirect)
+	Reference   string // ID tagihan di sisi gateway (token Snap, ID invoice Xendit, transaction_id Core API)
+	QRString    string // Isi QRIS untuk dirender di layar POS
+	QRImageURL  string // Gambar QR dari gateway, jika tersedia
+	Bank        string
+	VANumber    string
+}
+
+// GatewayStatus adalah status terbaru sebuah pembayaran menurut gateway
+type GatewayStatus struct {
+	OrderID     string
+	Status      string
+	GrossAmount float64
+	PaymentType string
+}
+
+// PaymentGateway adalah penyedia pembayaran online (Midtrans, Xendit, atau palsu untuk pengujian)
+type PaymentGateway interface {
+	Name() string
+	// SupportsMethod memeriksa apakah gateway bisa membuat tagihan dengan cara pembayaran tersebut
+	SupportsMethod(method string) bool
+	CreateCharge(ctx context.Context, req ChargeRequest) (ChargeResult, error)
+	Status(ctx context.Context, orderID string) (GatewayStatus, error)
+	Refund(ctx context.Context, orderID, refundKey string, amount float64, reason string) error
+	Cancel(ctx context.Context, orderID string) error
+	// VerifyNotification memeriksa keaslian webhook lalu mengubahnya menjadi status pembayaran
+	VerifyNotification(header http.Header, body []byte) (GatewayStatus, error)
+}
+
+// NewPaymentGateway mengembalikan gateway sesuai penyedia yang dipilih toko.
+// Jika PAYMENT_GATEWAY_MODE=fake, semua penyedia memakai FakeGateway sehingga tidak ada panggilan ke gateway asli.
+func NewPaymentGateway(provider string) (PaymentGateway, error) {
+	if os.Getenv("PAYMENT_GATEWAY_MODE") == "fake" || provider == ProviderFake {
+		return SharedFakeGateway(), nil
+	}
+
+	switch provider {
+	case "", ProviderMidtrans:
+		return NewMidtransGateway(), nil
+	case ProviderXendit:
+		return NewXenditGateway()
+	default:
+		return nil, fmt.Errorf("payment gateway %q tidak dikenal", provider)
+	}
+}
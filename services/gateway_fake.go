@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+)
+
+// FakeGateway menyimpan pembayaran di memori, dipakai untuk pengembangan lokal dan pengujian.
+// Status diubah lewat SetStatus atau dengan mengirim webhook {"order_id", "transaction_status"}.
+type FakeGateway struct {
+	mu       sync.Mutex
+	payments map[string]*GatewayStatus
+	refunds  map[string]float64 // refund key -> jumlah, agar refund berulang tidak dihitung dua kali
+}
+
+var (
+	fakeGatewayOnce sync.Once
+	fakeGateway     *FakeGateway
+)
+
+// SharedFakeGateway mengembalikan satu FakeGateway yang dipakai bersama oleh semua request
+func SharedFakeGateway() *FakeGateway {
+	fakeGatewayOnce.Do(func() {
+		fakeGateway = &FakeGateway{payments: map[string]*GatewayStatus{}, refunds: map[string]float64{}}
+	})
+	return fakeGateway
+}
+
+func (g *FakeGateway) Name() string {
+	return ProviderFake
+}
+
+func (g *FakeGateway) SupportsMethod(method string) bool {
+	return true
+}
+
+func (g *FakeGateway) CreateCharge(ctx context.Context, req ChargeRequest) (ChargeResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	orderID := req.Payment.OrderID
+	g.payments[orderID] = &GatewayStatus{
+		OrderID:     orderID,
+		Status:      GatewayPending,
+		GrossAmount: math.Round(req.Payment.GrossAmount),
+	}
+	log.Printf("[fake gateway] charge %s sebesar %.0f", orderID, req.Payment.GrossAmount)
+
+	result := ChargeResult{Reference: "fake-" + orderID}
+	switch req.Method {
+	case ChargeQRIS:
+		result.QRString = "00020101021226FAKEQRIS" + orderID
+	case ChargeVirtualAccount:
+		result.Bank = req.Bank
+		result.VANumber = fmt.Sprintf("8808%012d", len(g.payments))
+	default:
+		result.RedirectURL = "https://fake-gateway.local/pay/" + orderID
+	}
+	return result, nil
+}
+
+func (g *FakeGateway) Status(ctx context.Context, orderID string) (GatewayStatus, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment, ok := g.payments[orderID]
+	if !ok {
+		return GatewayStatus{}, fmt.Errorf("pembayaran %s tidak ditemukan", orderID)
+	}
+	return *payment, nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, orderID, refundKey string, amount float64, reason string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	log.Printf("[fake gateway] refund %s sebesar %.0f (%s)", orderID, amount, refundKey)
+	if _, done := g.refunds[refundKey]; done {
+		return nil
+	}
+	g.refunds[refundKey] = amount
+	if payment, ok := g.payments[orderID]; ok {
+		payment.Status = GatewayPartialRefund
+	}
+	return nil
+}
+
+func (g *FakeGateway) Cancel(ctx context.Context, orderID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	log.Printf("[fake gateway] cancel %s", orderID)
+	if payment, ok := g.payments[orderID]; ok {
+		payment.Status = GatewayCancel
+	}
+	return nil
+}
+
+// VerifyNotification menerima payload bergaya Midtrans tanpa memeriksa signature
+func (g *FakeGateway) VerifyNotification(header http.Header, body []byte) (GatewayStatus, error) {
+	var payload struct {
+		OrderID           string `json:"order_id"`
+		TransactionStatus string `json:"transaction_status"`
+		PaymentType       string `json:"payment_type"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.OrderID == "" || payload.TransactionStatus == "" {
+		return GatewayStatus{}, fmt.Errorf("payload tidak valid")
+	}
+
+	g.SetStatus(payload.OrderID, payload.TransactionStatus)
+	status, _ := g.Status(context.Background(), payload.OrderID)
+	status.PaymentType = payload.PaymentType
+	return status, nil
+}
+
+// SetStatus mengubah status pembayaran palsu, misalnya untuk mensimulasikan pembayaran berhasil
+func (g *FakeGateway) SetStatus(orderID, status string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment, ok := g.payments[orderID]
+	if !ok {
+		payment = &GatewayStatus{OrderID: orderID}
+		g.payments[orderID] = payment
+	}
+	payment.Status = status
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/veritrans/go-midtrans"
+)
+
+// MidtransGateway membuat pembayaran lewat Snap dan memakai Core API untuk status, refund, dan pembatalan
+type MidtransGateway struct {
+	client    midtrans.Client
+	serverKey string
+}
+
+// NewMidtransGateway memakai client dari MidtransClient
+func NewMidtransGateway() *MidtransGateway {
+	return &MidtransGateway{client: *MidtransClient(), serverKey: serverKey}
+}
+
+func (g *MidtransGateway) Name() string {
+	return ProviderMidtrans
+}
+
+func (g *MidtransGateway) SupportsMethod(method string) bool {
+	return method == "" || method == ChargeRedirect || method == ChargeQRIS || method == ChargeVirtualAccount
+}
+
+func (g *MidtransGateway) CreateCharge(ctx context.Context, req ChargeRequest) (ChargeResult, error) {
+	cfg := LoadSnapConfig()
+	if req.Expiry > 0 {
+		cfg.Expiry = req.Expiry
+	}
+	if req.Method == ChargeQRIS || req.Method == ChargeVirtualAccount {
+		return g.chargeDirect(req, cfg)
+	}
+
+	snap := midtrans.SnapGateway{Client: g.client}
+	resp, err := snap.GetToken(BuildSnapRequest(req.Payment, req.Transaction, req.Customer, cfg, req.Start))
+	if err != nil {
+		return ChargeResult{}, err
+	}
+	return ChargeResult{RedirectURL: resp.RedirectURL, Reference: resp.Token}, nil
+}
+
+// Respons Core API /v2/charge yang dipakai untuk QRIS dan virtual account
+type midtransChargeResponse struct {
+	StatusCode      string              `json:"status_code"`
+	StatusMessage   string              `json:"status_message"`
+	TransactionID   string              `json:"transaction_id"`
+	QRString        string              `json:"qr_string"`
+	Actions         []midtrans.Action   `json:"actions"`
+	VANumbers       []midtrans.VANumber `json:"va_numbers"`
+	PermataVANumber string              `json:"permata_va_number"`
+}
+
+// chargeDirect membuat QRIS atau virtual account lewat Core API agar bisa ditampilkan langsung di layar POS
+func (g *MidtransGateway) chargeDirect(req ChargeRequest, cfg SnapConfig) (ChargeResult, error) {
+	grossAmount := int64(math.Round(req.Payment.GrossAmount))
+	body := midtrans.ChargeReqWithMap{
+		"payment_type": req.Method,
+		"transaction_details": midtrans.TransactionDetails{
+			OrderID:  req.Payment.OrderID,
+			GrossAmt: grossAmount,
+		},
+		"item_details":     snapItems(req.Transaction, grossAmount),
+		"customer_details": snapCustomer(req.Transaction, req.Customer),
+		"custom_expiry": map[string]interface{}{
+			"order_time":      req.Start.Format("2006-01-02 15:04:05 -0700"),
+			"expiry_duration": int64(cfg.Expiry / time.Minute),
+			"unit":            "minute",
+		},
+	}
+	switch req.Method {
+	case ChargeQRIS:
+		body["qris"] = map[string]string{"acquirer": "gopay"}
+	case ChargeVirtualAccount:
+		if !VirtualAccountBanks[req.Bank] {
+			return ChargeResult{}, fmt.Errorf("bank virtual account %q tidak didukung", req.Bank)
+		}
+		body["bank_transfer"] = map[string]string{"bank": req.Bank}
+	}
+
+	core := midtrans.CoreGateway{Client: g.client}
+	raw, err := core.ChargeWithMap(&body)
+	if err != nil {
+		return ChargeResult{}, err
+	}
+
+	// ResponseWithMap diubah ke struct agar field yang dibutuhkan mudah dibaca
+	var resp midtransChargeResponse
+	encoded, _ := json.Marshal(raw)
+	if err := json.Unmarshal(encoded, &resp); err != nil {
+		return ChargeResult{}, err
+	}
+	if resp.StatusCode != "201" && resp.StatusCode != "200" {
+		return ChargeResult{}, fmt.Errorf("midtrans menolak charge: %s %s", resp.StatusCode, resp.StatusMessage)
+	}
+
+	result := ChargeResult{Reference: resp.TransactionID, QRString: resp.QRString, Bank: req.Bank}
+	for _, action := range resp.Actions {
+		if action.Name == "generate-qr-code" {
+			result.QRImageURL = action.URL
+		}
+	}
+	if len(resp.VANumbers) > 0 {
+		result.Bank = resp.VANumbers[0].Bank
+		result.VANumber = resp.VANumbers[0].VANumber
+	}
+	if resp.PermataVANumber != "" {
+		result.VANumber = resp.PermataVANumber
+	}
+	return result, nil
+}
+
+func (g *MidtransGateway) Status(ctx context.Context, orderID string) (GatewayStatus, error) {
+	core := midtrans.CoreGateway{Client: g.client}
+	resp, err := core.Status(orderID)
+	if err != nil {
+		return GatewayStatus{}, err
+	}
+	if resp.StatusCode != "200" && resp.StatusCode != "201" {
+		return GatewayStatus{}, fmt.Errorf("midtrans membalas status %s: %s", resp.StatusCode, resp.StatusMessage)
+	}
+	amount, _ := strconv.ParseFloat(resp.GrossAmount, 64)
+	return GatewayStatus{
+		OrderID:     resp.OrderID,
+		Status:      resp.TransactionStatus,
+		GrossAmount: amount,
+		PaymentType: resp.PaymentType,
+	}, nil
+}
+
+func (g *MidtransGateway) Refund(ctx context.Context, orderID, refundKey string, amount float64, reason string) error {
+	core := midtrans.CoreGateway{Client: g.client}
+	resp, err := core.Refund(orderID, &midtrans.RefundReq{
+		RefundKey: refundKey,
+		Amount:    int64(math.Round(amount)),
+		Reason:    reason,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != "200" {
+		return fmt.Errorf("midtrans menolak refund: %s %s", resp.StatusCode, resp.StatusMessage)
+	}
+	return nil
+}
+
+func (g *MidtransGateway) Cancel(ctx context.Context, orderID string) error {
+	core := midtrans.CoreGateway{Client: g.client}
+	resp, err := core.Cancel(orderID)
+	if err != nil {
+		return err
+	}
+	// Link Snap yang belum pernah dibuka customer belum tercatat di Core API (404), tidak ada yang perlu dibatalkan
+	if resp.StatusCode == "404" {
+		return nil
+	}
+	if resp.StatusCode != "200" {
+		return fmt.Errorf("midtrans menolak pembatalan: %s %s", resp.StatusCode, resp.StatusMessage)
+	}
+	return nil
+}
+
+// VerifyNotification mencocokkan signature_key = SHA512(order_id + status_code + gross_amount + server key)
+func (g *MidtransGateway) VerifyNotification(header http.Header, body []byte) (GatewayStatus, error) {
+	var payload struct {
+		OrderID           string `json:"order_id"`
+		StatusCode        string `json:"status_code"`
+		GrossAmount       string `json:"gross_amount"`
+		SignatureKey      string `json:"signature_key"`
+		TransactionStatus string `json:"transaction_status"`
+		FraudStatus       string `json:"fraud_status"`
+		PaymentType       string `json:"payment_type"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return GatewayStatus{}, fmt.Errorf("payload tidak valid")
+	}
+	if payload.OrderID == "" || payload.TransactionStatus == "" {
+		return GatewayStatus{}, fmt.Errorf("order_id atau transaction_status kosong")
+	}
+
+	sum := sha512.Sum512([]byte(payload.OrderID + payload.StatusCode + payload.GrossAmount + g.serverKey))
+	expected := hex.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(payload.SignatureKey)) != 1 {
+		return GatewayStatus{}, fmt.Errorf("signature tidak valid")
+	}
+
+	// Pembayaran kartu yang ditahan fraud detection belum dianggap diterima
+	status := payload.TransactionStatus
+	if status == "capture" && payload.FraudStatus == "challenge" {
+		status = GatewayPending
+	}
+
+	amount, _ := strconv.ParseFloat(payload.GrossAmount, 64)
+	return GatewayStatus{
+		OrderID:     payload.OrderID,
+		Status:      status,
+		GrossAmount: amount,
+		PaymentType: payload.PaymentType,
+	}, nil
+}
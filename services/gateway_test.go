@@ -0,0 +1,27 @@
+package services
+
+import "testing"
+
+func TestSupportsMethod(t *testing.T) {
+	tests := []struct {
+		gateway PaymentGateway
+		method  string
+		want    bool
+	}{
+		{&MidtransGateway{}, ChargeRedirect, true},
+		{&MidtransGateway{}, ChargeQRIS, true},
+		{&MidtransGateway{}, ChargeVirtualAccount, true},
+		{&MidtransGateway{}, "cash", false},
+		{&XenditGateway{}, ChargeRedirect, true},
+		{&XenditGateway{}, "", true},
+		{&XenditGateway{}, ChargeQRIS, false},
+		{&XenditGateway{}, ChargeVirtualAccount, false},
+		{SharedFakeGateway(), ChargeVirtualAccount, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.gateway.SupportsMethod(tt.method); got != tt.want {
+			t.Errorf("%s SupportsMethod(%q) = %v, want %v", tt.gateway.Name(), tt.method, got, tt.want)
+		}
+	}
+}
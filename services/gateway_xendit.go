@@ -0,0 +1,247 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// XenditGateway membuat pembayaran lewat Xendit Invoice API
+type XenditGateway struct {
+	APIURL        string
+	SecretKey     string
+	CallbackToken string
+	client        *http.Client
+}
+
+// NewXenditGateway membaca XENDIT_SECRET_KEY, XENDIT_CALLBACK_TOKEN, dan XENDIT_API_URL (opsional)
+func NewXenditGateway() (*XenditGateway, error) {
+	secretKey := os.Getenv("XENDIT_SECRET_KEY")
+	if secretKey == "" {
+		return nil, fmt.Errorf("XENDIT_SECRET_KEY belum diatur")
+	}
+	apiURL := os.Getenv("XENDIT_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.xendit.co"
+	}
+	return &XenditGateway{
+		APIURL:        strings.TrimRight(apiURL, "/"),
+		SecretKey:     secretKey,
+		CallbackToken: os.Getenv("XENDIT_CALLBACK_TOKEN"),
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Model invoice Xendit yang dipakai
+type xenditInvoice struct {
+	ID            string  `json:"id"`
+	ExternalID    string  `json:"external_id"`
+	Status        string  `json:"status"`
+	Amount        float64 `json:"amount"`
+	InvoiceURL    string  `json:"invoice_url"`
+	PaymentMethod string  `json:"payment_method"`
+}
+
+func (g *XenditGateway) Name() string {
+	return ProviderXendit
+}
+
+// SupportsMethod hanya menerima halaman invoice, QRIS dan virtual account dipilih customer di halaman tersebut
+func (g *XenditGateway) SupportsMethod(method string) bool {
+	return method == "" || method == ChargeRedirect
+}
+
+func (g *XenditGateway) CreateCharge(ctx context.Context, req ChargeRequest) (ChargeResult, error) {
+	if !g.SupportsMethod(req.Method) {
+		return ChargeResult{}, fmt.Errorf("xendit hanya mendukung pembayaran lewat halaman invoice")
+	}
+
+	cfg := LoadSnapConfig()
+	expiry := cfg.Expiry
+	if req.Expiry > 0 {
+		expiry = req.Expiry
+	}
+
+	// Item invoice sama dengan item Snap agar totalnya cocok dengan jumlah tagihan
+	amount := int64(math.Round(req.Payment.GrossAmount))
+	items := []map[string]interface{}{}
+	for _, item := range snapItems(req.Transaction, amount) {
+		items = append(items, map[string]interface{}{
+			"name":     item.Name,
+			"quantity": item.Qty,
+			"price":    item.Price,
+		})
+	}
+
+	customer := map[string]interface{}{"given_names": req.Customer.FullName}
+	if req.Customer.Email != "" {
+		customer["email"] = req.Customer.Email
+	}
+	if req.Customer.PhoneNumber != "" {
+		customer["mobile_number"] = req.Customer.PhoneNumber
+	}
+
+	body := map[string]interface{}{
+		"external_id":      req.Payment.OrderID,
+		"amount":           amount,
+		"description":      "Pembayaran laundry " + req.Transaction.OrderNumber,
+		"invoice_duration": int64(expiry / time.Second),
+		"currency":         "IDR",
+		"customer":         customer,
+		"items":            items,
+	}
+	if req.Customer.Email != "" {
+		body["payer_email"] = req.Customer.Email
+	}
+	if cfg.FinishURL != "" {
+		body["success_redirect_url"] = cfg.FinishURL
+	}
+	if cfg.ErrorURL != "" {
+		body["failure_redirect_url"] = cfg.ErrorURL
+	}
+
+	var invoice xenditInvoice
+	if err := g.call(ctx, http.MethodPost, "/v2/invoices", "", body, &invoice); err != nil {
+		return ChargeResult{}, err
+	}
+	return ChargeResult{RedirectURL: invoice.InvoiceURL, Reference: invoice.ID}, nil
+}
+
+func (g *XenditGateway) Status(ctx context.Context, orderID string) (GatewayStatus, error) {
+	invoice, err := g.findInvoice(ctx, orderID)
+	if err != nil {
+		return GatewayStatus{}, err
+	}
+	return GatewayStatus{
+		OrderID:     invoice.ExternalID,
+		Status:      xenditStatus(invoice.Status),
+		GrossAmount: invoice.Amount,
+		PaymentType: invoice.PaymentMethod,
+	}, nil
+}
+
+func (g *XenditGateway) Refund(ctx context.Context, orderID, refundKey string, amount float64, reason string) error {
+	invoice, err := g.findInvoice(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"invoice_id":   invoice.ID,
+		"reference_id": refundKey,
+		"amount":       int64(math.Round(amount)),
+		"currency":     "IDR",
+		"reason":       xenditRefundReason(reason),
+		"metadata":     map[string]string{"note": reason},
+	}
+	return g.call(ctx, http.MethodPost, "/refunds", refundKey, body, nil)
+}
+
+func (g *XenditGateway) Cancel(ctx context.Context, orderID string) error {
+	invoice, err := g.findInvoice(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	return g.call(ctx, http.MethodPost, "/invoices/"+url.PathEscape(invoice.ID)+"/expire!", "", nil, nil)
+}
+
+// VerifyNotification mencocokkan header x-callback-token dengan token verifikasi dari dashboard Xendit
+func (g *XenditGateway) VerifyNotification(header http.Header, body []byte) (GatewayStatus, error) {
+	token := header.Get("X-Callback-Token")
+	if g.CallbackToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(g.CallbackToken)) != 1 {
+		return GatewayStatus{}, fmt.Errorf("callback token tidak valid")
+	}
+
+	var invoice xenditInvoice
+	if err := json.Unmarshal(body, &invoice); err != nil || invoice.ExternalID == "" {
+		return GatewayStatus{}, fmt.Errorf("payload tidak valid")
+	}
+	return GatewayStatus{
+		OrderID:     invoice.ExternalID,
+		Status:      xenditStatus(invoice.Status),
+		GrossAmount: invoice.Amount,
+		PaymentType: invoice.PaymentMethod,
+	}, nil
+}
+
+// findInvoice mencari invoice berdasarkan external_id (order_id pembayaran)
+func (g *XenditGateway) findInvoice(ctx context.Context, orderID string) (xenditInvoice, error) {
+	var invoices []xenditInvoice
+	path := "/v2/invoices?external_id=" + url.QueryEscape(orderID)
+	if err := g.call(ctx, http.MethodGet, path, "", nil, &invoices); err != nil {
+		return xenditInvoice{}, err
+	}
+	if len(invoices) == 0 {
+		return xenditInvoice{}, fmt.Errorf("invoice xendit untuk %s tidak ditemukan", orderID)
+	}
+	return invoices[0], nil
+}
+
+// call mengirim request ke Xendit dengan basic auth secret key
+func (g *XenditGateway) call(ctx context.Context, method, path, idempotencyKey string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.APIURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(g.SecretKey, "")
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-key", idempotencyKey)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("xendit membalas %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+// xenditStatus mengubah status invoice Xendit menjadi istilah status yang dipakai di koleksi payments
+func xenditStatus(status string) string {
+	switch strings.ToUpper(status) {
+	case "PAID", "SETTLED":
+		return GatewaySettlement
+	case "EXPIRED":
+		return GatewayExpire
+	default:
+		return GatewayPending
+	}
+}
+
+// xenditRefundReason memetakan kode alasan refund ke alasan yang diterima Xendit
+func xenditRefundReason(reason string) string {
+	switch {
+	case strings.HasPrefix(reason, "duplicate"):
+		return "DUPLICATE"
+	case strings.HasPrefix(reason, "cancelled"):
+		return "CANCELLATION"
+	default:
+		return "REQUESTED_BY_CUSTOMER"
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Jenis kejadian yang memicu notifikasi ke customer
+const (
+	EventOrderReceived   = "order_received"
+	EventOrderReady      = "order_ready"
+	EventPaymentReceived = "payment_received"
+
+	EventUncollectedReminder = "uncollected_reminder"
+	EventUnpaidReminder      = "unpaid_reminder"
+)
+
+// NotificationData adalah isian untuk template pesan
+type NotificationData struct {
+	CustomerName        string
+	ShopName            string
+	OrderNumber         string
+	TotalAmount         string
+	Amount              string
+	EstimatedCompletion string
+	TrackingCode        string
+	StorageFee          string
+	DaysWaiting         int
+}
+
+type notificationTemplate struct {
+	Subject string
+	Body    string
+}
+
+// Template pesan per kejadian dan bahasa ("id" atau "en")
+var notificationTemplates = map[string]map[string]notificationTemplate{
+	EventOrderReceived: {
+		"id": {
+			Subject: "Order {{.OrderNumber}} diterima",
+			Body: "Halo {{.CustomerName}}, cucian Anda dengan nomor order {{.OrderNumber}} sudah kami terima di {{.ShopName}}. " +
+				"Total {{.TotalAmount}}.{{if .EstimatedCompletion}} Perkiraan selesai {{.EstimatedCompletion}}.{{end}}" +
+				"{{if .TrackingCode}} Kode lacak: {{.TrackingCode}}.{{end}}",
+		},
+		"en": {
+			Subject: "Order {{.OrderNumber}} received",
+			Body: "Hi {{.CustomerName}}, we have received your laundry at {{.ShopName}} with order number {{.OrderNumber}}. " +
+				"Total {{.TotalAmount}}.{{if .EstimatedCompletion}} Estimated ready {{.EstimatedCompletion}}.{{end}}" +
+				"{{if .TrackingCode}} Tracking code: {{.TrackingCode}}.{{end}}",
+		},
+	},
+	EventOrderReady: {
+		"id": {
+			Subject: "Order {{.OrderNumber}} siap diambil",
+			Body:    "Halo {{.CustomerName}}, cucian Anda dengan nomor order {{.OrderNumber}} sudah selesai dan siap diambil di {{.ShopName}}.",
+		},
+		"en": {
+			Subject: "Order {{.OrderNumber}} ready for pickup",
+			Body:    "Hi {{.CustomerName}}, your laundry with order number {{.OrderNumber}} is ready for pickup at {{.ShopName}}.",
+		},
+	},
+	EventPaymentReceived: {
+		"id": {
+			Subject: "Pembayaran order {{.OrderNumber}} diterima",
+			Body:    "Halo {{.CustomerName}}, pembayaran sebesar {{.Amount}} untuk order {{.OrderNumber}} sudah kami terima. Terima kasih!",
+		},
+		"en": {
+			Subject: "Payment for order {{.OrderNumber}} received",
+			Body:    "Hi {{.CustomerName}}, we have received your payment of {{.Amount}} for order {{.OrderNumber}}. Thank you!",
+		},
+	},
+	EventUncollectedReminder: {
+		"id": {
+			Subject: "Cucian {{.OrderNumber}} belum diambil",
+			Body: "Halo {{.CustomerName}}, cucian Anda dengan nomor order {{.OrderNumber}} sudah siap sejak {{.DaysWaiting}} hari lalu dan belum diambil di {{.ShopName}}." +
+				"{{if .StorageFee}} Biaya penyimpanan saat ini {{.StorageFee}}.{{end}}",
+		},
+		"en": {
+			Subject: "Laundry {{.OrderNumber}} not yet collected",
+			Body: "Hi {{.CustomerName}}, your laundry with order number {{.OrderNumber}} has been ready for {{.DaysWaiting}} days and is waiting at {{.ShopName}}." +
+				"{{if .StorageFee}} Current storage fee is {{.StorageFee}}.{{end}}",
+		},
+	},
+	EventUnpaidReminder: {
+		"id": {
+			Subject: "Tagihan order {{.OrderNumber}}",
+			Body:    "Halo {{.CustomerName}}, order {{.OrderNumber}} di {{.ShopName}} masih memiliki tagihan {{.Amount}}. Mohon segera melakukan pembayaran.",
+		},
+		"en": {
+			Subject: "Outstanding bill for order {{.OrderNumber}}",
+			Body:    "Hi {{.CustomerName}}, order {{.OrderNumber}} at {{.ShopName}} still has an outstanding balance of {{.Amount}}. Please complete your payment.",
+		},
+	},
+}
+
+// RenderNotification menyusun judul dan isi pesan, bahasa yang tidak dikenal memakai bahasa Indonesia
+func RenderNotification(event, lang string, data NotificationData) (string, string, error) {
+	templates, ok := notificationTemplates[event]
+	if !ok {
+		return "", "", fmt.Errorf("template notifikasi %q tidak ditemukan", event)
+	}
+	tmpl, ok := templates[lang]
+	if !ok {
+		tmpl = templates["id"]
+	}
+
+	subject, err := renderText(tmpl.Subject, data)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := renderText(tmpl.Body, data)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+func renderText(text string, data NotificationData) (string, error) {
+	tmpl, err := template.New("notification").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderNotification(t *testing.T) {
+	data := NotificationData{
+		CustomerName:        "Budi",
+		ShopName:            "Laundry Bersih",
+		OrderNumber:         "LDR-20261019-0001",
+		TotalAmount:         "Rp 45.000",
+		Amount:              "Rp 20.000",
+		EstimatedCompletion: "20/10/2026 10:00",
+		TrackingCode:        "A1B2C3",
+		StorageFee:          "Rp 5.000",
+		DaysWaiting:         4,
+	}
+
+	// Semua template harus bisa dirender dan memakai isian yang diberikan
+	for event, templates := range notificationTemplates {
+		for lang := range templates {
+			subject, body, err := RenderNotification(event, lang, data)
+			if err != nil {
+				t.Errorf("%s/%s: %v", event, lang, err)
+				continue
+			}
+			if !strings.Contains(subject, data.OrderNumber) || !strings.Contains(body, data.OrderNumber) {
+				t.Errorf("%s/%s tidak memuat nomor order: %q / %q", event, lang, subject, body)
+			}
+			if strings.Contains(subject+body, "<no value>") {
+				t.Errorf("%s/%s memuat isian kosong: %q", event, lang, body)
+			}
+		}
+		if _, ok := templates["id"]; !ok {
+			t.Errorf("%s tidak memiliki template bahasa Indonesia", event)
+		}
+	}
+}
+
+func TestRenderNotificationLanguage(t *testing.T) {
+	data := NotificationData{CustomerName: "Budi", OrderNumber: "LDR-20261019-0001", ShopName: "Laundry Bersih"}
+
+	_, english, err := RenderNotification(EventOrderReady, "en", data)
+	if err != nil || !strings.HasPrefix(english, "Hi Budi") {
+		t.Errorf("template en = %q, %v", english, err)
+	}
+	// Bahasa yang tidak dikenal memakai bahasa Indonesia
+	_, fallback, err := RenderNotification(EventOrderReady, "fr", data)
+	if err != nil || !strings.HasPrefix(fallback, "Halo Budi") {
+		t.Errorf("template fr = %q, %v", fallback, err)
+	}
+	if _, _, err := RenderNotification("unknown_event", "id", data); err == nil {
+		t.Error("kejadian yang tidak dikenal seharusnya error")
+	}
+}
+
+func TestRenderNotificationOptionalFields(t *testing.T) {
+	data := NotificationData{CustomerName: "Budi", OrderNumber: "LDR-20261019-0001", TotalAmount: "Rp 45.000"}
+
+	_, body, err := RenderNotification(EventOrderReceived, "id", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(body, "Perkiraan selesai") || strings.Contains(body, "Kode lacak") {
+		t.Errorf("isian kosong seharusnya tidak ditampilkan: %q", body)
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// Kanal pengiriman notifikasi
+const (
+	ChannelWhatsApp = "whatsapp"
+	ChannelSMS      = "sms"
+	ChannelEmail    = "email"
+)
+
+// Message adalah pesan yang dikirim ke customer
+type Message struct {
+	To      string // Nomor telepon atau alamat email tujuan
+	Subject string // Hanya dipakai untuk email
+	Body    string
+}
+
+// Notifier adalah penyedia pengiriman notifikasi (WhatsApp, SMS, email)
+type Notifier interface {
+	Channel() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewNotifier mengembalikan penyedia untuk kanal tertentu berdasarkan environment variable.
+// Jika NOTIFIER_MODE=fake, semua kanal memakai FakeNotifier sehingga tidak ada pesan yang benar-benar dikirim.
+func NewNotifier(channel string) (Notifier, error) {
+	if os.Getenv("NOTIFIER_MODE") == "fake" {
+		return fakeFor(channel), nil
+	}
+
+	switch channel {
+	case ChannelWhatsApp:
+		return NewWhatsAppNotifier()
+	case ChannelSMS:
+		return NewSMSNotifier()
+	case ChannelEmail:
+		return NewEmailNotifier()
+	default:
+		return nil, fmt.Errorf("kanal notifikasi %q tidak dikenal", channel)
+	}
+}
+
+// FakeNotifier menyimpan pesan di memori, dipakai untuk pengembangan lokal dan pengujian.
+// Kegagalan provider bisa disimulasikan dengan SetError.
+type FakeNotifier struct {
+	channel string
+	mu      sync.Mutex
+	sent    []Message
+	err     error
+}
+
+var (
+	fakeNotifiersMu sync.Mutex
+	fakeNotifiers   = map[string]*FakeNotifier{}
+)
+
+// fakeFor mengembalikan FakeNotifier yang sama untuk setiap kanal agar pesan bisa diperiksa
+func fakeFor(channel string) *FakeNotifier {
+	fakeNotifiersMu.Lock()
+	defer fakeNotifiersMu.Unlock()
+	if fake, ok := fakeNotifiers[channel]; ok {
+		return fake
+	}
+	fake := &FakeNotifier{channel: channel}
+	fakeNotifiers[channel] = fake
+	return fake
+}
+
+// SharedFakeNotifier mengembalikan FakeNotifier yang dipakai NewNotifier untuk kanal tertentu saat NOTIFIER_MODE=fake
+func SharedFakeNotifier(channel string) *FakeNotifier {
+	return fakeFor(channel)
+}
+
+func (f *FakeNotifier) Channel() string {
+	return f.channel
+}
+
+func (f *FakeNotifier) Send(ctx context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, msg)
+	log.Printf("[notifier:%s] ke %s: %s", f.channel, msg.To, msg.Body)
+	return nil
+}
+
+// Sent mengembalikan salinan semua pesan yang sudah "dikirim"
+func (f *FakeNotifier) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message{}, f.sent...)
+}
+
+// SetError membuat pengiriman berikutnya gagal dengan err, nil berarti pengiriman kembali berhasil
+func (f *FakeNotifier) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// Reset menghapus pesan yang tersimpan dan kegagalan yang disimulasikan
+func (f *FakeNotifier) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = nil
+	f.err = nil
+}
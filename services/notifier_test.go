@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFakeNotifier(t *testing.T) {
+	t.Setenv("NOTIFIER_MODE", "fake")
+	notifier, err := NewNotifier(ChannelWhatsApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := SharedFakeNotifier(ChannelWhatsApp)
+	if notifier != Notifier(fake) {
+		t.Fatal("NewNotifier seharusnya memakai FakeNotifier bersama")
+	}
+	fake.Reset()
+	defer fake.Reset()
+
+	fake.SetError(errors.New("provider sedang gangguan"))
+	if err := notifier.Send(context.Background(), Message{To: "0812", Body: "gagal"}); err == nil {
+		t.Error("Send seharusnya gagal")
+	}
+	fake.SetError(nil)
+	if err := notifier.Send(context.Background(), Message{To: "0812", Body: "berhasil"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := fake.Sent()
+	if len(sent) != 1 || sent[0].Body != "berhasil" {
+		t.Errorf("pesan terkirim = %+v, want hanya pesan yang berhasil", sent)
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMSNotifier mengirim SMS melalui gateway HTTP (format API Zenziva)
+type SMSNotifier struct {
+	APIURL  string
+	UserKey string
+	PassKey string
+	client  *http.Client
+}
+
+// NewSMSNotifier membaca SMS_API_URL, SMS_USER_KEY, dan SMS_PASS_KEY
+func NewSMSNotifier() (*SMSNotifier, error) {
+	userKey, passKey := os.Getenv("SMS_USER_KEY"), os.Getenv("SMS_PASS_KEY")
+	if userKey == "" || passKey == "" {
+		return nil, fmt.Errorf("SMS_USER_KEY dan SMS_PASS_KEY belum diatur")
+	}
+	apiURL := os.Getenv("SMS_API_URL")
+	if apiURL == "" {
+		apiURL = "https://console.zenziva.net/reguler/api/sendsms/"
+	}
+	return &SMSNotifier{APIURL: apiURL, UserKey: userKey, PassKey: passKey, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (n *SMSNotifier) Channel() string {
+	return ChannelSMS
+}
+
+func (n *SMSNotifier) Send(ctx context.Context, msg Message) error {
+	form := url.Values{}
+	form.Set("userkey", n.UserKey)
+	form.Set("passkey", n.PassKey)
+	form.Set("to", msg.To)
+	form.Set("message", msg.Body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.APIURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return doNotifierRequest(n.client, req)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"fmt"
+	"laundry-pos/models"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/veritrans/go-midtrans"
+)
+
+// Batas panjang nama item dari Midtrans
+const snapItemNameMax = 50
+
+// SnapConfig adalah pengaturan halaman pembayaran Snap dari environment variable
+type SnapConfig struct {
+	EnabledPayments []midtrans.PaymentType // MIDTRANS_ENABLED_PAYMENTS, misalnya "gopay,shopeepay,other_qris,bca_va"
+	Expiry          time.Duration          // MIDTRANS_EXPIRY_MINUTES, default 60 menit
+	FinishURL       string                 // MIDTRANS_FINISH_URL, tujuan redirect setelah pembayaran
+	UnfinishURL     string                 // MIDTRANS_UNFINISH_URL, untuk callback onPending/onClose di Snap.js
+	ErrorURL        string                 // MIDTRANS_ERROR_URL, untuk callback onError di Snap.js
+}
+
+// LoadSnapConfig membaca pengaturan Snap, kosong berarti memakai pengaturan dashboard Midtrans
+func LoadSnapConfig() SnapConfig {
+	cfg := SnapConfig{
+		Expiry:      60 * time.Minute,
+		FinishURL:   os.Getenv("MIDTRANS_FINISH_URL"),
+		UnfinishURL: os.Getenv("MIDTRANS_UNFINISH_URL"),
+		ErrorURL:    os.Getenv("MIDTRANS_ERROR_URL"),
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("MIDTRANS_EXPIRY_MINUTES")); err == nil && minutes > 0 {
+		cfg.Expiry = time.Duration(minutes) * time.Minute
+	}
+	for _, payment := range strings.Split(os.Getenv("MIDTRANS_ENABLED_PAYMENTS"), ",") {
+		if payment = strings.TrimSpace(payment); payment != "" {
+			cfg.EnabledPayments = append(cfg.EnabledPayments, midtrans.PaymentType(payment))
+		}
+	}
+	return cfg
+}
+
+// BuildSnapRequest menyusun permintaan Snap lengkap: detail customer, item, kanal pembayaran, masa berlaku, dan redirect.
+// Jumlah harga item selalu sama dengan gross amount karena Midtrans menolak permintaan yang tidak cocok.
+func BuildSnapRequest(payment models.Payment, transaction models.Transaction, customer models.Customer, cfg SnapConfig, start time.Time) *midtrans.SnapReq {
+	grossAmount := int64(math.Round(payment.GrossAmount))
+	items := snapItems(transaction, grossAmount)
+
+	req := &midtrans.SnapReq{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  payment.OrderID,
+			GrossAmt: grossAmount,
+		},
+		EnabledPayments: cfg.EnabledPayments,
+		Items:           &items,
+		CustomerDetail:  snapCustomer(transaction, customer),
+		Expiry: &midtrans.ExpiryDetail{
+			StartTime: start.Format("2006-01-02 15:04:05 -0700"),
+			Unit:      "minute",
+			Duration:  int64(cfg.Expiry / time.Minute),
+		},
+		CustomField1: transaction.OrderNumber,
+	}
+	if cfg.FinishURL != "" {
+		req.Callbacks = &midtrans.Callbacks{Finish: cfg.FinishURL}
+	}
+	return req
+}
+
+// snapItems mengubah item transaksi menjadi item Midtrans, ditambah baris penyesuaian untuk DP/cicilan
+func snapItems(transaction models.Transaction, grossAmount int64) []midtrans.ItemDetail {
+	items := []midtrans.ItemDetail{}
+	var sum int64
+	add := func(item midtrans.ItemDetail) {
+		item.Name = truncateItemName(item.Name)
+		items = append(items, item)
+		sum += item.Price * int64(item.Qty)
+	}
+
+	for i, item := range transaction.Items {
+		name := item.Service.ServiceName
+		if item.SpeedTier != "" && item.SpeedTier != models.SpeedRegular {
+			name += " (" + item.SpeedTier + ")"
+		}
+		id := item.ServiceID.Hex()
+		if item.ServiceID.IsZero() {
+			id = fmt.Sprintf("ITEM-%d", i+1)
+		}
+
+		// Harga satuan dibulatkan, sisa pembulatan ikut dimasukkan ke biaya tambahan agar total tetap sama
+		unitPrice := int64(math.Round(item.UnitPrice))
+		quantity := int32(item.Quantity)
+		if quantity <= 0 {
+			quantity = 1
+		}
+		rest := int64(math.Round(item.TotalPrice)) - unitPrice*int64(quantity)
+		add(midtrans.ItemDetail{ID: id, Name: name, Price: unitPrice, Qty: quantity, Category: item.Service.Unit})
+		if rest != 0 {
+			add(midtrans.ItemDetail{ID: id + "-EXTRA", Name: "Biaya tambahan " + name, Price: rest, Qty: 1})
+		}
+	}
+	if fee := int64(math.Round(transaction.DeliveryFee)); fee != 0 {
+		add(midtrans.ItemDetail{ID: "DELIVERY", Name: "Ongkos antar-jemput", Price: fee, Qty: 1})
+	}
+	if fee := int64(math.Round(transaction.StorageFee)); fee != 0 {
+		add(midtrans.ItemDetail{ID: "STORAGE", Name: "Biaya penyimpanan", Price: fee, Qty: 1})
+	}
+
+	// Pembayaran sebelumnya dan sisa yang dibayar nanti ditampilkan sebagai pengurang
+	if paid := int64(math.Round(transaction.AmountPaid)); paid > 0 {
+		add(midtrans.ItemDetail{ID: "PAID", Name: "Sudah dibayar", Price: -paid, Qty: 1})
+	}
+	if diff := grossAmount - sum; diff < 0 {
+		add(midtrans.ItemDetail{ID: "BALANCE", Name: "Sisa tagihan dibayar kemudian", Price: diff, Qty: 1})
+	} else if diff > 0 {
+		add(midtrans.ItemDetail{ID: "ADJUSTMENT", Name: "Penyesuaian", Price: diff, Qty: 1})
+	}
+	return items
+}
+
+// snapCustomer mengisi detail customer, alamat antar dipakai sebagai alamat pengiriman jika ada
+func snapCustomer(transaction models.Transaction, customer models.Customer) *midtrans.CustDetail {
+	firstName, lastName := customer.FullName, ""
+	if i := strings.Index(customer.FullName, " "); i > 0 {
+		firstName, lastName = customer.FullName[:i], strings.TrimSpace(customer.FullName[i+1:])
+	}
+
+	detail := &midtrans.CustDetail{
+		FName: firstName,
+		LName: lastName,
+		Email: customer.Email,
+		Phone: customer.PhoneNumber,
+	}
+	if transaction.Delivery != nil && transaction.Delivery.Address != "" {
+		detail.ShipAddr = &midtrans.CustAddress{
+			FName:       firstName,
+			LName:       lastName,
+			Phone:       customer.PhoneNumber,
+			Address:     transaction.Delivery.Address,
+			CountryCode: "IDN",
+		}
+	}
+	return detail
+}
+
+// truncateItemName memotong nama item sesuai batas Midtrans tanpa memotong karakter multibyte
+func truncateItemName(name string) string {
+	runes := []rune(name)
+	if len(runes) <= snapItemNameMax {
+		return name
+	}
+	return strings.TrimSpace(string(runes[:snapItemNameMax]))
+}
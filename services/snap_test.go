@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+
+	"laundry-pos/models"
+)
+
+func TestSnapItemsAddUpToGrossAmount(t *testing.T) {
+	kiloan := models.Service{ServiceName: "Cuci Kering", Unit: "kg"}
+	tests := []struct {
+		name        string
+		transaction models.Transaction
+		grossAmount int64
+	}{
+		{
+			name: "lunas sekaligus",
+			transaction: models.Transaction{
+				Items:       []models.TransactionItem{{Service: kiloan, Quantity: 3, UnitPrice: 7000, TotalPrice: 21000}},
+				TotalAmount: 21000,
+			},
+			grossAmount: 21000,
+		},
+		{
+			name: "harga satuan dibulatkan",
+			transaction: models.Transaction{
+				Items:       []models.TransactionItem{{Service: kiloan, Quantity: 3, UnitPrice: 3333.33, TotalPrice: 10000}},
+				TotalAmount: 10000,
+			},
+			grossAmount: 10000,
+		},
+		{
+			name: "uang muka",
+			transaction: models.Transaction{
+				Items:       []models.TransactionItem{{Service: kiloan, Quantity: 5, UnitPrice: 8000, TotalPrice: 40000}},
+				DeliveryFee: 5000,
+				TotalAmount: 45000,
+			},
+			grossAmount: 20000,
+		},
+		{
+			name: "pelunasan setelah uang muka",
+			transaction: models.Transaction{
+				Items:       []models.TransactionItem{{Service: kiloan, Quantity: 5, UnitPrice: 8000, TotalPrice: 40000}},
+				DeliveryFee: 5000,
+				TotalAmount: 45000,
+				AmountPaid:  20000,
+			},
+			grossAmount: 25000,
+		},
+		{
+			name: "cicilan kedua",
+			transaction: models.Transaction{
+				Items:       []models.TransactionItem{{Service: kiloan, Quantity: 2, UnitPrice: 12500.5, TotalPrice: 25001}},
+				StorageFee:  3000,
+				TotalAmount: 28001,
+				AmountPaid:  10000,
+			},
+			grossAmount: 8000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sum int64
+			for _, item := range snapItems(tt.transaction, tt.grossAmount) {
+				sum += item.Price * int64(item.Qty)
+			}
+			if sum != tt.grossAmount {
+				t.Errorf("jumlah item = %d, want %d", sum, tt.grossAmount)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// WhatsAppNotifier mengirim pesan melalui WhatsApp gateway (format API Fonnte)
+type WhatsAppNotifier struct {
+	APIURL string
+	Token  string
+	client *http.Client
+}
+
+// NewWhatsAppNotifier membaca WHATSAPP_API_URL dan WHATSAPP_API_TOKEN
+func NewWhatsAppNotifier() (*WhatsAppNotifier, error) {
+	token := os.Getenv("WHATSAPP_API_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("WHATSAPP_API_TOKEN belum diatur")
+	}
+	apiURL := os.Getenv("WHATSAPP_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.fonnte.com/send"
+	}
+	return &WhatsAppNotifier{APIURL: apiURL, Token: token, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (n *WhatsAppNotifier) Channel() string {
+	return ChannelWhatsApp
+}
+
+func (n *WhatsAppNotifier) Send(ctx context.Context, msg Message) error {
+	form := url.Values{}
+	form.Set("target", msg.To)
+	form.Set("message", msg.Body)
+	form.Set("countryCode", "62")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.APIURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", n.Token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return doNotifierRequest(n.client, req)
+}
+
+// doNotifierRequest menjalankan request ke penyedia dan menganggap status selain 2xx sebagai gagal
+func doNotifierRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("penyedia notifikasi membalas %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// Karakter kode tanpa huruf/angka yang mudah tertukar (0/O, 1/I)
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// RandomCode membuat kode acak sepanjang n karakter untuk tag atau verifikasi
+func RandomCode(n int) string {
+	code := make([]byte, n)
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := range code {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err) // crypto/rand tidak seharusnya gagal
+		}
+		code[i] = codeAlphabet[idx.Int64()]
+	}
+	return string(code)
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Perataan teks ESC/POS
+const (
+	AlignLeft   byte = 0
+	AlignCenter byte = 1
+	AlignRight  byte = 2
+)
+
+// EscPos menyusun perintah untuk printer thermal ESC/POS
+type EscPos struct {
+	buf   bytes.Buffer
+	Width int // Jumlah karakter per baris
+}
+
+// NewEscPos membuat penyusun perintah untuk kertas 58mm (32 karakter) atau 80mm (48 karakter)
+func NewEscPos(paperMM int) *EscPos {
+	p := &EscPos{Width: 32}
+	if paperMM >= 80 {
+		p.Width = 48
+	}
+	p.buf.Write([]byte{0x1B, 0x40}) // ESC @ : reset printer
+	return p
+}
+
+// Align mengatur perataan teks berikutnya
+func (p *EscPos) Align(align byte) {
+	p.buf.Write([]byte{0x1B, 0x61, align})
+}
+
+// Bold menyalakan atau mematikan huruf tebal
+func (p *EscPos) Bold(on bool) {
+	p.buf.Write([]byte{0x1B, 0x45, boolByte(on)})
+}
+
+// DoubleSize menyalakan atau mematikan huruf ukuran ganda
+func (p *EscPos) DoubleSize(on bool) {
+	size := byte(0x00)
+	if on {
+		size = 0x11
+	}
+	p.buf.Write([]byte{0x1D, 0x21, size})
+}
+
+// Line mencetak satu baris teks, teks panjang dipotong menjadi beberapa baris
+func (p *EscPos) Line(text string) {
+	text = asciiOnly(text)
+	for len(text) > p.Width {
+		p.buf.WriteString(text[:p.Width] + "\n")
+		text = text[p.Width:]
+	}
+	p.buf.WriteString(text + "\n")
+}
+
+// Columns mencetak teks kiri dan kanan dalam satu baris
+func (p *EscPos) Columns(left, right string) {
+	left, right = asciiOnly(left), asciiOnly(right)
+	space := p.Width - len(left) - len(right)
+	if space < 1 {
+		p.Line(left)
+		p.buf.WriteString(strings.Repeat(" ", max(p.Width-len(right), 0)) + right + "\n")
+		return
+	}
+	p.buf.WriteString(left + strings.Repeat(" ", space) + right + "\n")
+}
+
+// Separator mencetak garis pemisah selebar kertas
+func (p *EscPos) Separator() {
+	p.buf.WriteString(strings.Repeat("-", p.Width) + "\n")
+}
+
+// QRCode mencetak QR code memakai perintah bawaan printer (GS ( k)
+func (p *EscPos) QRCode(data string, moduleSize byte) {
+	data = asciiOnly(data)
+	// Model 2
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, 0x04, 0x00, 0x31, 0x41, 0x32, 0x00})
+	// Ukuran modul
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x43, moduleSize})
+	// Tingkat koreksi kesalahan M
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x45, 0x31})
+	// Simpan data
+	length := len(data) + 3
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, byte(length % 256), byte(length / 256), 0x31, 0x50, 0x30})
+	p.buf.WriteString(data)
+	// Cetak
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x51, 0x30})
+	p.buf.WriteString("\n")
+}
+
+// Feed menambahkan baris kosong
+func (p *EscPos) Feed(lines int) {
+	p.buf.Write([]byte{0x1B, 0x64, byte(lines)})
+}
+
+// Cut memotong kertas (partial cut)
+func (p *EscPos) Cut() {
+	p.buf.Write([]byte{0x1D, 0x56, 0x41, 0x03})
+}
+
+// Bytes mengembalikan seluruh perintah yang sudah disusun
+func (p *EscPos) Bytes() []byte {
+	return p.buf.Bytes()
+}
+
+func boolByte(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+// asciiOnly mengganti karakter non-ASCII karena printer thermal umumnya hanya mendukung ASCII
+func asciiOnly(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' || r == '\t' {
+			return ' '
+		}
+		if r < 32 || r > 126 {
+			return '?'
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatRupiah memformat angka menjadi "Rp 16.000" dengan pemisah ribuan titik
+func FormatRupiah(amount float64) string {
+	negative := amount < 0
+	digits := strconv.FormatInt(int64(math.Round(math.Abs(amount))), 10)
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+
+	if negative {
+		return "-Rp " + b.String()
+	}
+	return "Rp " + b.String()
+}
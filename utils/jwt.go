@@ -1,6 +1,7 @@
 package utils
 
 import (
+    "context"
     "github.com/dgrijalva/jwt-go"
     "time"
     "os"
@@ -11,8 +12,9 @@ var jwtKey = []byte(os.Getenv("JWT_SECRET_KEY")) // Load the secret key from env
 
 // JWTClaims defines the structure of the JWT claims
 type JWTClaims struct {
-    UserID string `json:"user_id"`
-    Role   string `json:"role"` // Add role to the claims
+    UserID   string `json:"user_id"`
+    Role     string `json:"role"` // Add role to the claims
+    Username string `json:"username"`
     jwt.StandardClaims
 }
 
@@ -20,8 +22,9 @@ type JWTClaims struct {
 func GenerateJWT(userID, role, username string) (string, error) {
     expirationTime := time.Now().Add(24 * time.Hour) // Mengatur token berlaku 24 jam
     claims := &JWTClaims{
-        UserID: userID,
-        Role:   role,
+        UserID:   userID,
+        Role:     role,
+        Username: username,
         StandardClaims: jwt.StandardClaims{
             ExpiresAt: expirationTime.Unix(), // Waktu kedaluwarsa
             IssuedAt:  time.Now().Unix(),     // Waktu diterbitkan
@@ -67,6 +70,22 @@ func ValidateJWT(tokenStr string) (*JWTClaims, error) {
 }
 
 
+type contextKey string
+
+const claimsContextKey contextKey = "claims"
+
+// WithClaims menyimpan klaim JWT yang sudah divalidasi ke dalam context request
+func WithClaims(ctx context.Context, claims *JWTClaims) context.Context {
+    return context.WithValue(ctx, claimsContextKey, claims)
+}
+
+// ClaimsFromContext mengambil klaim JWT yang disimpan oleh middleware
+func ClaimsFromContext(ctx context.Context) (*JWTClaims, bool) {
+    claims, ok := ctx.Value(claimsContextKey).(*JWTClaims)
+    return claims, ok
+}
+
+
 var blacklist = struct {
 	sync.RWMutex
 	tokens map[string]time.Time
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"laundry-pos/models"
+	"time"
+	_ "time/tzdata" // Data zona waktu ikut di-embed karena server tidak selalu menyediakannya
+)
+
+// ShopLocation mengembalikan zona waktu toko, default WIB jika tidak diatur
+func ShopLocation(settings models.ShopSettings) *time.Location {
+	if settings.Timezone != "" {
+		if loc, err := time.LoadLocation(settings.Timezone); err == nil {
+			return loc
+		}
+	}
+	// Zona waktu tidak tersedia di server (misalnya Vercel tanpa tzdata)
+	return time.FixedZone("WIB", 7*60*60)
+}
+
+// IsWorkingDay memeriksa apakah toko buka pada tanggal tersebut
+func IsWorkingDay(day time.Time, settings models.ShopSettings) bool {
+	for _, closed := range settings.ClosedDays {
+		if int(day.Weekday()) == closed {
+			return false
+		}
+	}
+	date := day.Format("2006-01-02")
+	for _, holiday := range settings.Holidays {
+		if holiday == date {
+			return false
+		}
+	}
+	return true
+}
+
+// AddWorkingHours menambahkan durasi pengerjaan dengan hanya menghitung jam buka toko
+func AddWorkingHours(start time.Time, hours int, settings models.ShopSettings) time.Time {
+	loc := ShopLocation(settings)
+	remaining := time.Duration(hours) * time.Hour
+	current := start.In(loc)
+
+	openH, openM, ok1 := parseClock(settings.OpenTime)
+	closeH, closeM, ok2 := parseClock(settings.CloseTime)
+	if !ok1 || !ok2 || closeH*60+closeM <= openH*60+openM {
+		// Jam kerja tidak valid, anggap toko buka 24 jam
+		return start.Add(remaining)
+	}
+
+	// Batasi pencarian agar tidak berputar terus jika semua hari ditandai libur
+	for i := 0; i < 366; i++ {
+		year, month, day := current.Date()
+		open := time.Date(year, month, day, openH, openM, 0, 0, loc)
+		close := time.Date(year, month, day, closeH, closeM, 0, 0, loc)
+		nextDay := time.Date(year, month, day+1, openH, openM, 0, 0, loc)
+
+		if !IsWorkingDay(current, settings) || !current.Before(close) {
+			current = nextDay
+			continue
+		}
+		if current.Before(open) {
+			current = open
+		}
+
+		available := close.Sub(current)
+		if remaining <= available {
+			return current.Add(remaining)
+		}
+		remaining -= available
+		current = nextDay
+	}
+
+	return start.Add(time.Duration(hours) * time.Hour)
+}
+
+// parseClock membaca jam dengan format "HH:MM"
+func parseClock(value string) (int, int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, false
+	}
+	return t.Hour(), t.Minute(), true
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"laundry-pos/models"
+)
+
+func TestAddWorkingHours(t *testing.T) {
+	settings := models.ShopSettings{
+		OpenTime:   "08:00",
+		CloseTime:  "20:00",
+		Timezone:   "Asia/Jakarta",
+		ClosedDays: []int{int(time.Sunday)},
+		Holidays:   []string{"2026-10-21"},
+	}
+	loc := ShopLocation(settings)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, loc)
+	}
+
+	// 16 Oktober 2026 hari Jumat, 18 Oktober hari Minggu
+	tests := []struct {
+		name     string
+		settings models.ShopSettings
+		start    time.Time
+		hours    int
+		want     time.Time
+	}{
+		{"selesai di hari yang sama", settings, at(16, 9, 0), 3, at(16, 12, 0)},
+		{"tepat saat tutup", settings, at(16, 17, 0), 3, at(16, 20, 0)},
+		{"sisa dilanjutkan besok", settings, at(16, 18, 0), 4, at(17, 10, 0)},
+		{"sebelum buka", settings, at(16, 6, 30), 2, at(16, 10, 0)},
+		{"setelah tutup", settings, at(16, 21, 0), 1, at(17, 9, 0)},
+		{"melewati hari Minggu", settings, at(17, 19, 0), 2, at(19, 9, 0)},
+		{"melewati hari libur", settings, at(20, 19, 0), 2, at(22, 9, 0)},
+		{"nol jam di luar jam kerja", settings, at(18, 10, 0), 0, at(19, 8, 0)},
+		{"jam kerja tidak valid", models.ShopSettings{Timezone: "Asia/Jakarta"}, at(18, 22, 0), 5, at(19, 3, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddWorkingHours(tt.start, tt.hours, tt.settings); !got.Equal(tt.want) {
+				t.Errorf("AddWorkingHours(%v, %d) = %v, want %v", tt.start, tt.hours, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportLocale menentukan format angka dan tanggal pada file ekspor
+type ExportLocale struct {
+	Name           string
+	Delimiter      rune   // Pemisah kolom CSV
+	DecimalSep     string // Pemisah desimal CSV
+	ThousandsSep   string // Pemisah ribuan CSV, kosong berarti tanpa pemisah
+	DateLayout     string // Layout tanggal CSV
+	XLSXDateFormat string // Format tanggal sel Excel
+}
+
+// Format Indonesia memakai titik untuk ribuan dan koma untuk desimal, sehingga kolom CSV dipisah titik koma
+// seperti yang diharapkan Excel berbahasa Indonesia
+var (
+	LocaleID = ExportLocale{Name: "id", Delimiter: ';', DecimalSep: ",", ThousandsSep: ".", DateLayout: "02/01/2006 15:04", XLSXDateFormat: "dd/mm/yyyy hh:mm"}
+	LocaleEN = ExportLocale{Name: "en", Delimiter: ',', DecimalSep: ".", DateLayout: "2006-01-02 15:04", XLSXDateFormat: "yyyy-mm-dd hh:mm"}
+)
+
+// ExportLocaleFor mengembalikan format ekspor berdasarkan nama, default Indonesia
+func ExportLocaleFor(name string) (ExportLocale, bool) {
+	switch name {
+	case "", LocaleID.Name:
+		return LocaleID, true
+	case LocaleEN.Name:
+		return LocaleEN, true
+	default:
+		return ExportLocale{}, false
+	}
+}
+
+// FormatNumber memformat angka sesuai locale, misalnya 1234567.5 menjadi "1.234.567,5"
+func (l ExportLocale) FormatNumber(value float64) string {
+	text := strconv.FormatFloat(value, 'f', -1, 64)
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	whole, fraction := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		whole, fraction = text[:i], text[i+1:]
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, d := range whole {
+		if i > 0 && l.ThousandsSep != "" && (len(whole)-i)%3 == 0 {
+			b.WriteString(l.ThousandsSep)
+		}
+		b.WriteRune(d)
+	}
+	if fraction != "" {
+		b.WriteString(l.DecimalSep + fraction)
+	}
+	return b.String()
+}
+
+// SheetWriter menulis tabel baris demi baris ke CSV atau XLSX tanpa menampung seluruh data di memori.
+// Nilai sel boleh berupa string, angka, time.Time, *time.Time, atau nil.
+type SheetWriter interface {
+	WriteHeader(columns ...string) error
+	WriteRow(values ...interface{}) error
+	Close() error
+}
+
+// CSVSheet menulis tabel sebagai CSV
+type CSVSheet struct {
+	writer   *csv.Writer
+	locale   ExportLocale
+	location *time.Location
+	rows     int
+}
+
+// NewCSVSheet membuat penulis CSV. BOM UTF-8 ditulis lebih dulu agar Excel membaca huruf non-ASCII dengan benar.
+func NewCSVSheet(w io.Writer, locale ExportLocale, location *time.Location) (*CSVSheet, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = locale.Delimiter
+	return &CSVSheet{writer: writer, locale: locale, location: location}, nil
+}
+
+func (s *CSVSheet) WriteHeader(columns ...string) error {
+	return s.writer.Write(columns)
+}
+
+func (s *CSVSheet) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = s.format(value)
+	}
+	if err := s.writer.Write(record); err != nil {
+		return err
+	}
+	// Kirim ke client secara berkala agar data tidak menumpuk di buffer
+	s.rows++
+	if s.rows%200 == 0 {
+		s.writer.Flush()
+		return s.writer.Error()
+	}
+	return nil
+}
+
+func (s *CSVSheet) Close() error {
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+func (s *CSVSheet) format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return csvSafeText(v)
+	case float64:
+		return s.locale.FormatNumber(v)
+	case int:
+		return s.locale.FormatNumber(float64(v))
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.In(s.location).Format(s.locale.DateLayout)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return s.format(*v)
+	default:
+		return csvSafeText(fmt.Sprint(v))
+	}
+}
+
+// csvSafeText memberi awalan ' pada teks yang akan dibaca spreadsheet sebagai rumus (=, +, -, @, tab, CR),
+// misalnya nama customer atau catatan yang diisi bebas
+func csvSafeText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// Gaya sel pada styles.xml
+const (
+	xlsxStyleDefault = 0
+	xlsxStyleDate    = 1
+	xlsxStyleHeader  = 2
+)
+
+// XLSXSheet menulis tabel sebagai workbook XLSX satu sheet. Sheet ditulis langsung ke zip
+// dan teks memakai inline string sehingga tidak perlu menampung sharedStrings di memori.
+type XLSXSheet struct {
+	zip      *zip.Writer
+	sheet    *bufio.Writer
+	location *time.Location
+	row      int
+}
+
+// NewXLSXSheet membuat penulis XLSX dengan nama sheet dan format tanggal sesuai locale
+func NewXLSXSheet(w io.Writer, sheetName string, locale ExportLocale, location *time.Location) (*XLSXSheet, error) {
+	archive := zip.NewWriter(w)
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sheetName))
+	var dateFormat strings.Builder
+	xml.EscapeText(&dateFormat, []byte(locale.XLSXDateFormat))
+
+	parts := []struct{ path, content string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+		{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="` + dateFormat.String() + `"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`},
+	}
+	for _, part := range parts {
+		entry, err := archive.Create(part.path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(entry)
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return &XLSXSheet{zip: archive, sheet: sheet, location: location}, nil
+}
+
+func (s *XLSXSheet) WriteHeader(columns ...string) error {
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = column
+	}
+	return s.writeRow(values, xlsxStyleHeader)
+}
+
+func (s *XLSXSheet) WriteRow(values ...interface{}) error {
+	return s.writeRow(values, xlsxStyleDefault)
+}
+
+func (s *XLSXSheet) writeRow(values []interface{}, style int) error {
+	s.row++
+	fmt.Fprintf(s.sheet, `<row r="%d">`, s.row)
+	for i, value := range values {
+		ref := xlsxColumn(i) + strconv.Itoa(s.row)
+		switch v := value.(type) {
+		case nil:
+			continue
+		case *time.Time:
+			if v == nil {
+				continue
+			}
+			value = *v
+		}
+
+		switch v := value.(type) {
+		case float64:
+			fmt.Fprintf(s.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+		case int:
+			fmt.Fprintf(s.sheet, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+		case time.Time:
+			if v.IsZero() {
+				continue
+			}
+			fmt.Fprintf(s.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleDate, strconv.FormatFloat(excelSerial(v.In(s.location)), 'f', -1, 64))
+		default:
+			fmt.Fprintf(s.sheet, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+			xml.EscapeText(s.sheet, []byte(fmt.Sprint(v)))
+			s.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := s.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (s *XLSXSheet) Close() error {
+	s.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := s.sheet.Flush(); err != nil {
+		return err
+	}
+	return s.zip.Close()
+}
+
+// xlsxColumn mengubah indeks kolom (0 = A) menjadi nama kolom Excel
+func xlsxColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// excelSerial mengubah waktu lokal menjadi nomor seri tanggal Excel (hari sejak 30 Desember 1899)
+func excelSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	days := wall.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+	return math.Round(days*86400) / 86400
+}
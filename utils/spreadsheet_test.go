@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExportLocaleFormatNumber(t *testing.T) {
+	tests := []struct {
+		locale ExportLocale
+		value  float64
+		want   string
+	}{
+		{LocaleID, 0, "0"},
+		{LocaleID, 999, "999"},
+		{LocaleID, 1000, "1.000"},
+		{LocaleID, 1234567.5, "1.234.567,5"},
+		{LocaleID, -15000, "-15.000"},
+		{LocaleEN, 1234567.5, "1234567.5"},
+		{LocaleEN, -0.25, "-0.25"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.locale.FormatNumber(tt.value); got != tt.want {
+			t.Errorf("%s FormatNumber(%v) = %q, want %q", tt.locale.Name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := xlsxColumn(tt.index); got != tt.want {
+			t.Errorf("xlsxColumn(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestExcelSerial(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	tests := []struct {
+		time time.Time
+		want float64
+	}{
+		{time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC), 61},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 45292},
+		{time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), 45292.5},
+		// Jam dinding lokal yang dipakai, bukan UTC
+		{time.Date(2024, 1, 1, 6, 0, 0, 0, wib), 45292.25},
+	}
+
+	for _, tt := range tests {
+		if got := excelSerial(tt.time); got != tt.want {
+			t.Errorf("excelSerial(%v) = %v, want %v", tt.time, got, tt.want)
+		}
+	}
+}
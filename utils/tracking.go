@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+)
+
+// ErrTrackingSecretMissing dikembalikan jika TRACKING_SECRET_KEY belum diatur
+var ErrTrackingSecretMissing = errors.New("TRACKING_SECRET_KEY belum diatur")
+
+// trackingSecret memakai TRACKING_SECRET_KEY. Kunci ini sengaja tidak berbagi dengan kunci JWT,
+// dan tanpa kunci token tidak dibuat maupun diterima agar tidak bisa dipalsukan.
+func trackingSecret() ([]byte, error) {
+	secret := os.Getenv("TRACKING_SECRET_KEY")
+	if secret == "" {
+		return nil, ErrTrackingSecretMissing
+	}
+	return []byte(secret), nil
+}
+
+// SignTrackingToken membuat token untuk link pelacakan order tanpa perlu kode verifikasi
+func SignTrackingToken(orderNumber string) (string, error) {
+	secret, err := trackingSecret()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(orderNumber))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// VerifyTrackingToken memeriksa token link pelacakan, selalu gagal jika kunci belum diatur
+func VerifyTrackingToken(orderNumber, token string) bool {
+	expected, err := SignTrackingToken(orderNumber)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(token))
+}